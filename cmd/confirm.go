@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/sottey/tududimport/internal/logging"
+)
+
+// confirmImport prints what plan is about to write and asks on stdin whether
+// to go ahead, defaulting to no. Without a terminal to ask on it refuses, so
+// unattended runs have to pass --yes.
+func confirmImport(plan importer.Plan) error {
+	if !logging.IsTerminal(os.Stdin) {
+		return errors.New("stdin is not a terminal; pass --yes to import without confirmation")
+	}
+
+	project := "none"
+	if plan.ProjectFromFolder || plan.ProjectMapFile != "" {
+		project = "from folders"
+	} else if plan.ProjectID >= 0 {
+		project = fmt.Sprint(plan.ProjectID)
+	}
+	fmt.Fprintf(os.Stderr, "About to import %d notes with %d distinct tags into %s (user %d, project %s).\n",
+		plan.Notes, plan.Tags, plan.DBPath, plan.UserID, project)
+	fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("import cancelled")
+}
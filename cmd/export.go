@@ -0,0 +1,55 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the user's notes from the database to a markdown tree under --out",
+	Long: `Write every note of the user in --db as a markdown file under --out,
+the reverse of an import. Nothing in the database is changed.
+
+Each file starts with frontmatter holding the note's title, tags, created and
+updated dates, followed by a "# Title" heading and the stored content. Notes
+go in a folder per part of their first tag (by name), e.g. work/client-a,
+and untagged notes sit directly under --out. Titles that clash get " (2)",
+" (3)" and so on. Existing files with the same names are overwritten.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "db", "out")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+
+		if _, err := importer.RunExport(cfg, importer.Options{Logger: logger}); err != nil {
+			logger.Fatalf("%v", err)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&cfg.ExportOut, "out", "o", "", "Directory to write the exported notes to (required)")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var mergeTagsCmd = &cobra.Command{
+	Use:   "merge-tags",
+	Short: "Merge the user's tags whose names differ only in case or punctuation",
+	Long: `Merge tag rows left behind by earlier imports that name the same tag,
+such as "Work" and "work" or "to do" and "to-do".
+
+Tags are grouped by their slug; in each group the oldest row is kept, links
+in notes_tags (and tasks_tags and projects_tags, when present) are
+repointed to it, and the other rows are deleted. Everything runs in one
+transaction, which --dry-run rolls back.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "db")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+
+		merges, err := importer.RunMergeTags(cfg, importer.Options{Logger: logger})
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		tags, repointed, dropped := 0, 0, 0
+		for _, m := range merges {
+			tags += len(m.MergedIDs)
+			repointed += m.Repointed
+			dropped += m.Dropped
+		}
+		verb := func(done, would string) string {
+			if cfg.DryRun {
+				return would
+			}
+			return done
+		}
+		logger.Summaryf("Summary:\n")
+		logger.Summaryf("  %-20s %d\n", verb("tags merged:", "would merge tags:"), tags)
+		logger.Summaryf("  %-20s %d\n", verb("links repointed:", "would repoint:"), repointed)
+		logger.Summaryf("  %-20s %d\n", "duplicates removed:", dropped)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeTagsCmd)
+}
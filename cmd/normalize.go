@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Write the notes under --root as normalized markdown to --out",
+	Long: `Parse every note under --root with the same settings as an import and
+write it to the same relative path under --out, without connecting to the
+database.
+
+Each file starts with a "# Title" heading for its resolved title, followed by
+the body as --frontmatter leaves it, with trailing whitespace trimmed (outside
+code fences) and leading/trailing blank lines dropped. With
+--rewrite-frontmatter a fresh frontmatter block holding the title, tags and
+dates replaces the original one (--frontmatter off is read as parse).
+--out must not be inside --root.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "root", "out")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+
+		if _, err := importer.RunNormalize(cfg, importer.Options{Logger: logger}); err != nil {
+			logger.Fatalf("%v", err)
+		}
+	},
+}
+
+func init() {
+	normalizeCmd.Flags().StringVarP(&cfg.NormalizeOut, "out", "o", "", "Directory to write the normalized tree to (required)")
+	normalizeCmd.Flags().BoolVar(&cfg.RewriteFrontmatter, "rewrite-frontmatter", false, "Start each file with a frontmatter block holding the resolved title, tags, created and updated")
+	rootCmd.AddCommand(normalizeCmd)
+}
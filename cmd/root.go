@@ -22,85 +22,226 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"database/sql"
-	"log"
+	"fmt"
 	"os"
+	"runtime"
+	"time"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/sottey/tududimport/internal/logging"
 	"github.com/sottey/tududimport/internal/models"
 	"github.com/sottey/tududimport/internal/utils"
 	"github.com/spf13/cobra"
 )
 
-var cfg models.Config
+var (
+	cfg          models.Config
+	configPath   string
+	verbose      bool
+	quiet        bool
+	showProgress bool
+	noColor      bool
+	assumeYes    bool
+	logFormat    string
+	since        string
+	until        string
+	createdAt    string
+	updatedAt    string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "tududimport",
 	Short: "Import a file system tree into Tududi's db directly",
-	Run: func(cmd *cobra.Command, args []string) {
-		db, err := sql.Open("sqlite3", cfg.DBPath)
-		if err != nil {
-			log.Fatalf("open db: %v", err)
-		}
-		defer db.Close()
+	Long: `Import a file system tree into Tududi's db directly.
 
-		if err := db.Ping(); err != nil {
-			log.Fatalf("ping db: %v", err)
+Settings can also be kept in a YAML file passed with --config, using flag
+names as keys. Precedence is: flag defaults < config file < command-line flags.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configPath == "" {
+			return nil
 		}
+		return loadConfigFile(cmd, configPath)
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "db", "root")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
 
-		log.Printf("Connected to DB: %s\n", cfg.DBPath)
-
-		notes, err := utils.DiscoverNotes(cfg)
-		if err != nil {
-			log.Fatalf("discover notes: %v", err)
+		opts := importer.Options{Logger: logger, Progress: showProgress}
+		if !assumeYes {
+			opts.Confirm = confirmImport
 		}
-		log.Printf("Discovered %d markdown files\n", len(notes))
-
-		tagCache := make(map[string]int64) // key: name|userID
-
-		tx, err := db.Begin()
+		summary, err := importer.RunImport(cfg, opts)
 		if err != nil {
-			log.Fatalf("begin tx: %v", err)
+			logger.Fatalf("%v", err)
 		}
-		defer func() {
-			if cfg.DryRun {
-				log.Println("DRY-RUN: rolling back transaction")
-				_ = tx.Rollback()
-			}
-		}()
-
-		for i, n := range notes {
-			log.Printf("[%d/%d] Importing %s\n", i+1, len(notes), n.Path)
-
-			noteID, err := utils.InsertNote(tx, cfg, n)
-			if err != nil {
-				log.Fatalf("insert note (%s): %v", n.Path, err)
-			}
-
-			uniqueTags := utils.UniqueStrings(n.Tags)
-			for _, t := range uniqueTags {
-				tagID, err := utils.GetOrCreateTag(tx, cfg, tagCache, t)
-				if err != nil {
-					log.Fatalf("get/create tag (%s): %v", t, err)
-				}
-				if err := utils.LinkNoteTag(tx, noteID, tagID); err != nil {
-					log.Fatalf("link note/tag (%d,%d): %v", noteID, tagID, err)
-				}
-			}
+		if cfg.Diff || cfg.Preview > 0 {
+			return
 		}
 
 		if cfg.DryRun {
-			log.Println("DRY-RUN complete, transaction rolled back.")
-			return
+			logger.Successf("DRY-RUN complete, transaction rolled back.\n")
+		} else {
+			logger.Successf("Import complete.\n")
 		}
+		printSummary(logger, cfg, summary)
+	},
+}
 
-		if err := tx.Commit(); err != nil {
-			log.Fatalf("commit tx: %v", err)
+// validateFlags checks the flag values shared by every command, and that the
+// required ones were set, then fills in the settings derived from them.
+func validateFlags(cmd *cobra.Command, required ...string) error {
+	// Cobra checks mutually exclusive flags before the config file is applied,
+	// so check again with its values in
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		return err
+	}
+	if err := utils.ValidateDriver(cfg.Driver); err != nil {
+		return err
+	}
+	if err := utils.ValidateDedupeMode(cfg.DedupeNotes); err != nil {
+		return err
+	}
+	if err := utils.ValidateTitleSources(cfg.TitleSources); err != nil {
+		return err
+	}
+	if err := utils.ValidatePragmas(cfg.Pragmas); err != nil {
+		return err
+	}
+	if err := utils.ValidateTagTransforms(cfg.TagTransforms); err != nil {
+		return err
+	}
+	if err := logging.ValidateFormat(logFormat); err != nil {
+		return err
+	}
+	if err := utils.ValidateTemplatePosition(cfg.TemplatePosition); err != nil {
+		return err
+	}
+	if cfg.BodyTemplate != "" {
+		if _, err := utils.ParseBodyTemplate(cfg.BodyTemplate); err != nil {
+			return err
 		}
+	}
+	if err := utils.ValidateFrontmatterMode(cfg.Frontmatter); err != nil {
+		return err
+	}
+	if err := utils.ValidateOversizeAction(cfg.OversizeAction); err != nil {
+		return err
+	}
+	if err := utils.ValidateHTMLExtensions(cfg.HTMLExtensions); err != nil {
+		return err
+	}
+	if err := utils.ValidateUIDFormat(cfg.UIDFormat); err != nil {
+		return err
+	}
+	if err := utils.ValidateLongTagAction(cfg.LongTagAction); err != nil {
+		return err
+	}
+	if err := utils.ValidateFormat(cfg.Format); err != nil {
+		return err
+	}
+	var err error
+	if cfg.Since, err = parseTimeFlag("since", since); err != nil {
+		return err
+	}
+	if cfg.Until, err = parseTimeFlag("until", until); err != nil {
+		return err
+	}
+	if cfg.FixedCreated, err = parseTimeFlag("created-at", createdAt); err != nil {
+		return err
+	}
+	if cfg.FixedUpdated, err = parseTimeFlag("updated-at", updatedAt); err != nil {
+		return err
+	}
+	if err := requireFlags(cmd, required...); err != nil {
+		return err
+	}
+	if len(cfg.Roots) > 0 {
+		cfg.Root = cfg.Roots[0]
+	}
+	return nil
+}
 
-		log.Println("Import complete.")
-	},
+// printSummary logs the final counts, phrased as "would ..." in dry-run.
+func printSummary(logger *logging.Logger, cfg models.Config, s models.Summary) {
+	verb := func(done, would string) string {
+		if cfg.DryRun {
+			return would
+		}
+		return done
+	}
+	logger.Summaryf("Summary:\n")
+	for _, r := range s.Roots {
+		logger.Summaryf("  %-20s %d  (%s)\n", "files in root:", r.Notes, r.Root)
+	}
+	logger.Summaryf("  %-20s %d\n", verb("notes inserted:", "would insert notes:"), s.NotesInserted)
+	if cfg.UpdateChanged {
+		logger.Summaryf("  %-20s %d\n", verb("notes updated:", "would update notes:"), s.NotesUpdated)
+	}
+	logger.Summaryf("  %-20s %d\n", "notes skipped:", s.NotesSkipped)
+	if cfg.TxPerNote || cfg.ContinueOnError {
+		logger.Summaryf("  %-20s %d\n", "notes failed:", s.NotesFailed)
+	}
+	if len(s.FileErrors) > 0 {
+		logger.Summaryf("  %-20s %d\n", "unreadable files:", len(s.FileErrors))
+	}
+	if cfg.SkipEmpty {
+		logger.Summaryf("  %-20s %d\n", "empty skipped:", s.Empty)
+	}
+	if cfg.SkipDrafts {
+		logger.Summaryf("  %-20s %d\n", "drafts skipped:", s.Drafts)
+	}
+	if cfg.OnlyTagged {
+		logger.Summaryf("  %-20s %d\n", "untagged skipped:", s.Untagged)
+	}
+	if cfg.DedupeNotes == utils.DedupeMerge {
+		logger.Summaryf("  %-20s %d\n", "notes merged:", s.Deduped)
+	} else if cfg.DedupeNotes == utils.DedupeFolder || cfg.DedupeNotes == utils.DedupeNumber {
+		logger.Summaryf("  %-20s %d\n", "titles renamed:", s.Deduped)
+	}
+	logger.Summaryf("  %-20s %d\n", verb("tags created:", "would create tags:"), s.TagsCreated)
+	logger.Summaryf("  %-20s %d\n", "tags reused:", s.TagsReused)
+	logger.Summaryf("  %-20s %d\n", verb("note-tag links:", "would link:"), s.Links)
+	logger.Summaryf("  %-20s %d\n", verb("tasks inserted:", "would insert tasks:"), s.TasksInserted)
+	if cfg.ImportNoteLinks {
+		logger.Summaryf("  %-20s %d\n", verb("note links:", "would link notes:"), s.NoteLinks)
+		logger.Summaryf("  %-20s %d\n", "unresolved links:", s.Unresolved)
+	}
+	logger.Summaryf("  %-20s %s\n", "elapsed:", s.Elapsed.Round(time.Millisecond))
+}
+
+// parseTimeFlag parses an optional RFC3339 flag value; empty means no bound.
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: want an RFC3339 time like 2024-05-01T00:00:00Z", name, value)
+	}
+	return t, nil
+}
+
+// newLogger builds the logger for the --quiet, --verbose, --no-color and
+// --log-format flags.
+func newLogger() *logging.Logger {
+	level := logging.LevelNormal
+	switch {
+	case quiet:
+		level = logging.LevelQuiet
+	case verbose:
+		level = logging.LevelVerbose
+	}
+	if logFormat == logging.FormatJSON {
+		return logging.NewJSON(os.Stderr, level)
+	}
+	logger := logging.New(os.Stderr, level)
+	logger.SetColor(!noColor && os.Getenv("NO_COLOR") == "" && logging.IsTerminal(os.Stderr))
+	return logger
 }
 
 func Execute() {
@@ -111,14 +252,114 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&cfg.DBPath, "db", "d", "", "Path to Tududi SQLite DB (required)")
-	rootCmd.PersistentFlags().StringVarP(&cfg.Root, "root", "r", "", "Root directory of markdown files (required)")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "YAML config file of flag-name: value settings (command-line flags override it)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Driver, "driver", utils.DriverSQLite, "Database driver: sqlite3 or postgres (Defaults to sqlite3)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.DBPath, "db", "d", "", "Path to Tududi SQLite DB, or a Postgres connection string with --driver postgres (required)")
+	rootCmd.PersistentFlags().StringArrayVarP(&cfg.Roots, "root", "r", nil, "Root directory of markdown files, a single markdown file, or a .zip/.tar.gz archive; repeatable to import several trees at once (required)")
 	rootCmd.PersistentFlags().IntVarP(&cfg.UserID, "user-id", "u", 1, "User ID to assign to imported notes and tags (Defaults to 1)")
+	rootCmd.PersistentFlags().StringVar(&cfg.UserEmail, "user-email", "", "Import as the user with this email instead of --user-id")
+	rootCmd.PersistentFlags().StringVar(&cfg.UIDFormat, "uid-format", utils.UIDShort, "Format of generated uid values: short (15 alphanumerics), uuid (v4) or nanoid (Defaults to short)")
 	rootCmd.PersistentFlags().IntVarP(&cfg.ProjectID, "project-id", "p", -1, "Project ID to assign to imported notes (-1 or omitted means no project)")
-	rootCmd.PersistentFlags().BoolVarP(&cfg.DryRun, "no-commit", "n", true, "Dry run (do not commit writes) (Defaults to true)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ProjectFromFolder, "project-from-folder", false, "Assign notes to a project named after their top-level folder, creating it if needed (notes directly under root use --project-id)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ProjectMapFile, "project-map", "", "CSV (folder,project_id) or JSON ({\"folder\": project_id}) file routing notes in a folder, or below it, to an existing project; unmapped notes keep the default")
+	rootCmd.PersistentFlags().StringVar(&cfg.Category, "category", "", "Set notes.category on every note (if the column exists)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.CategoryFromFolder, "category-from-folder", false, "Set notes.category to the note's top-level folder (if the column exists; notes directly under root use --category)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Area, "area", "", "Area to place projects created by --project-from-folder in, creating it if needed")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.DryRun, "dry-run", "n", false, "Dry run: roll back instead of committing writes (Defaults to false)")
+	rootCmd.PersistentFlags().IntVarP(&cfg.BatchSize, "batch-size", "b", 0, "Commit every N imported notes (0 means a single transaction; a dry run always uses one, rolled back at the end)")
+	rootCmd.PersistentFlags().StringVar(&cfg.EmitSQL, "emit-sql", "", "Write every INSERT/UPDATE the import executes, with values inlined, to this .sql file (with --dry-run, what would have been written)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ContinueOnError, "continue-on-error", false, "Roll back just a note that fails to import, log it and carry on; the rest of its batch is still committed")
+	rootCmd.PersistentFlags().StringVar(&cfg.ErrorFile, "error-file", "", "Write the source path and error of each note that failed (with --continue-on-error or --tx-per-note) to this CSV file")
+	rootCmd.PersistentFlags().BoolVar(&cfg.TxPerNote, "tx-per-note", false, "Commit each note in its own transaction; a note that fails is rolled back and reported, and the import moves on")
+	rootCmd.PersistentFlags().IntVar(&cfg.BusyRetries, "busy-retries", 5, "Retries, with exponential backoff from 100ms, when the database is locked (Defaults to 5)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.BusyTimeout, "busy-timeout", 5*time.Second, "How long SQLite waits for a lock before reporting the database busy (Defaults to 5s)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.Pragmas, "pragma", nil, "SQLite pragma to set on connect as key=value, e.g. cache_size=-64000; repeatable, and overrides the journal_mode=WAL, synchronous=NORMAL and busy_timeout defaults. Only busy_timeout is set for dry runs and read-only commands, so they leave the database file as it was")
+	rootCmd.PersistentFlags().StringVar(&cfg.DedupeNotes, "dedupe-notes", "", "Handle notes sharing a title: merge (combine bodies into one note), folder (append the folder name to the title) or number (Title, Title (2), Title (3) in path order)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Diff, "diff", false, "Compare discovered notes with the user's existing notes (NEW / UPDATED / DUPLICATE by title) and exit without writing")
+	rootCmd.PersistentFlags().IntVar(&cfg.DryRunLimit, "dry-run-limit", 0, "Stop discovery after this many markdown files across all roots, for quick experiments; results are then not exhaustive (needs --dry-run, --diff or --preview when importing; 0 means off)")
+	rootCmd.PersistentFlags().IntVar(&cfg.Preview, "preview", 0, "Parse only the first N discovered files, print their title, tags and dates, and exit without writing (0 means off)")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.SkipExisting, "skip-existing", "s", false, "Skip notes that already exist for the user with the same title and content")
+	rootCmd.PersistentFlags().BoolVar(&cfg.UpdateChanged, "update-changed", false, "Update notes previously imported from the same source path when their content hash changed, and skip unchanged ones; an updated note's tags are replaced with the file's, while its project and tasks are left as they are (needs notes.source_path and notes.content_hash)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.SkipEmpty, "skip-empty", false, "Skip notes whose body is empty or only whitespace once frontmatter is removed")
+	rootCmd.PersistentFlags().BoolVar(&cfg.SkipTitleOnly, "skip-title-only", false, "With --skip-empty, also skip notes that contain only a heading")
+	rootCmd.PersistentFlags().BoolVar(&cfg.SkipDrafts, "skip-drafts", false, "Skip notes whose frontmatter has draft: true or publish: false, whatever the --frontmatter mode")
+	rootCmd.PersistentFlags().BoolVar(&cfg.OnlyTagged, "only-tagged", false, "Skip notes that end up with no tags from any source")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Pinned, "pinned", false, "Mark every imported note as pinned (if notes.pinned exists)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Archived, "archived", false, "Mark every imported note as archived (if notes.archived exists)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ImportTasks, "import-tasks", false, "Import markdown checkbox items (- [ ] / - [x]) as Tududi tasks in the note's project")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ImportNoteLinks, "import-note-links", false, "Record [[wikilinks]] between imported notes in the note_links table (source_note_id, target_note_id)")
+	rootCmd.PersistentFlags().StringVar(&cfg.StateFile, "state-file", "", "Record committed source paths here and skip them on re-runs, so interrupted imports can resume")
+	rootCmd.PersistentFlags().BoolVar(&cfg.RecordSource, "record-source", false, "Store each note's path relative to root in notes.source_path (if the column exists)")
+
+	// Discovery and parsing
+	rootCmd.PersistentFlags().StringArrayVarP(&cfg.Exclude, "exclude", "x", nil, "Glob pattern (relative to root, or a basename) of files/folders to skip; repeatable, any match excludes")
+	rootCmd.PersistentFlags().StringArrayVarP(&cfg.Include, "include", "i", nil, "Glob pattern (relative to root, or a basename) of markdown files to import; repeatable, excludes take precedence")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Extensions, "extensions", utils.DefaultExtensions, "Comma-separated file extensions treated as markdown, compared case-insensitively, e.g. md,markdown,mdown (Defaults to md)")
+	rootCmd.PersistentFlags().StringVar(&cfg.FilesFrom, "files-from", "", "Import only the files listed in this file, one path per line, instead of walking the roots; - reads stdin (pair with --yes when importing). Each file must sit under a --root, which stays the base for folder tags")
+	rootCmd.PersistentFlags().StringVar(&since, "since", "", "Only import files modified at or after this RFC3339 time, e.g. 2024-05-01T00:00:00Z")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "Only import files modified at or before this RFC3339 time")
+	rootCmd.PersistentFlags().BoolVar(&cfg.RespectGitignore, "respect-gitignore", false, "Skip files matched by .gitignore files under root (combined with --exclude)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.FailOnError, "fail-on-error", false, "Fail once discovery is done if any file couldn't be read or parsed, instead of warning and skipping it")
+	rootCmd.PersistentFlags().BoolVar(&cfg.FollowSymlinks, "follow-symlinks", false, "Descend into symlinked directories (each real directory is visited once)")
+	rootCmd.PersistentFlags().IntVarP(&cfg.Workers, "workers", "w", runtime.GOMAXPROCS(0), "Number of files to parse concurrently (Defaults to GOMAXPROCS)")
+	rootCmd.PersistentFlags().Int64Var(&cfg.MaxBodySize, "max-body-size", 0, "Largest file to import, in bytes; 0 means no limit (Defaults to 0)")
+	rootCmd.PersistentFlags().StringVar(&cfg.OversizeAction, "oversize-action", utils.OversizeSkip, "What to do with files over --max-body-size: skip or truncate (Defaults to skip)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Format, "format", utils.FormatMarkdown, "Source format: markdown, logseq to read title::, tags:: and alias:: page properties, or mmd to read and remove a MultiMarkdown Title:/Tags:/Keywords:/Date: header (Defaults to markdown)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.StripProperties, "strip-properties", false, "With --format logseq, remove the page property lines from the body")
+	rootCmd.PersistentFlags().StringVar(&cfg.Frontmatter, "frontmatter", utils.FrontmatterOff, "Leading YAML frontmatter: off (keep in body), strip (remove only) or parse (remove and use title/tags/dates/aliases) (Defaults to off)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.FrontmatterMeta, "frontmatter-to-metadata", false, "With --frontmatter parse, store frontmatter fields other than title, tags, aliases, created and updated as JSON in notes.metadata (if the column exists)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.TitleSources, "title-source", utils.DefaultTitleSources, "Ordered title sources to try: frontmatter, h1, h2, dataview (title:: field), aliases (first frontmatter alias), filename (Defaults to frontmatter,h1,aliases,filename)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.StripTitleMarkdown, "strip-title-markdown", false, "Remove inline markdown (bold, italic, code, links) from titles, leaving the body as is")
+	rootCmd.PersistentFlags().IntVar(&cfg.TitleMaxLength, "title-max-length", 0, "Truncate titles longer than this many characters on a word boundary, with an ellipsis (0 means unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.StripTitleHeading, "strip-title-heading", false, "Remove the heading line from the body when it's used as the title")
+	rootCmd.PersistentFlags().BoolVar(&cfg.CollapseBlankLines, "collapse-blank-lines", false, "Squash runs of three or more blank lines in note bodies into one, leaving fenced code blocks alone")
+	rootCmd.PersistentFlags().StringVar(&cfg.BodyTemplate, "body-template", "", "Go text/template added to every note body, with the note's .Title, .Path, .RelPath, .Tags, .CreatedAt and .UpdatedAt plus .Date (today), e.g. \"> Imported from {{.RelPath}} on {{.Date}}\"")
+	rootCmd.PersistentFlags().StringVar(&cfg.TemplatePosition, "template-position", utils.TemplatePrepend, "Where --body-template goes: prepend or append (Defaults to prepend)")
+	rootCmd.PersistentFlags().StringVar(&cfg.AttachmentsBase, "attachments-base", "", "Rewrite relative image and attachment links to absolute URLs under this base, keeping their path relative to root")
+	rootCmd.PersistentFlags().BoolVar(&cfg.RenderHTML, "render-html", false, "Store note bodies rendered to HTML instead of raw markdown (titles and tags still come from the markdown)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.HTMLExtensions, "html-extensions", nil, "Markdown extensions for --render-html: table, strikethrough, tasklist, linkify, or gfm for all of them")
+	rootCmd.PersistentFlags().BoolVar(&cfg.DatesFromGit, "dates-from-git", false, "Use the first and last git commit touching each file for created/updated (untracked files, and roots outside a git repository, keep their file times)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.DateFromFilename, "date-from-filename", false, "Use a date at the start of the file name (e.g. 2024-03-15 Meeting.md) for created/updated")
+	rootCmd.PersistentFlags().StringVar(&createdAt, "created-at", "", "RFC3339 time to use as every note's created date instead of file, git or filename dates; frontmatter created: still wins")
+	rootCmd.PersistentFlags().StringVar(&updatedAt, "updated-at", "", "RFC3339 time to use as every note's updated date instead of file, git or filename dates; frontmatter updated: still wins")
+	rootCmd.PersistentFlags().StringVar(&cfg.DatePattern, "date-pattern", "2006-01-02", "Go time layout for --date-from-filename; empty tries common formats such as 2024-01-02 and Jan 2, 2024 (Defaults to 2006-01-02)")
+
+	// Tags
 	rootCmd.PersistentFlags().BoolVarP(&cfg.TagFromFolders, "tag-from-folders", "f", true, "Create tags from folder hierarchy under root (Defaults to true)")
-	rootCmd.PersistentFlags().BoolVarP(&cfg.TagFromHashtags, "tag-from-hashtags", "t", true, "Create tags from inline #tags (Defaults to true)")
+	rootCmd.PersistentFlags().IntVar(&cfg.FolderTagDepth, "folder-tag-depth", 0, "Only create folder tags for the first N folders under root (0 means unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.FlattenFolderTags, "flatten-folder-tags", false, "Join the folder path into a single tag (cottage/foo/bar becomes cottage-foo-bar) instead of one tag per folder")
+	rootCmd.PersistentFlags().StringVar(&cfg.FlattenSeparator, "flatten-separator", "-", "Separator between folders with --flatten-folder-tags (Defaults to -)")
+	rootCmd.PersistentFlags().StringVar(&cfg.FolderTagPrefix, "folder-tag-prefix", "", "Prefix every folder tag with this namespace, e.g. vault1 turns clients into vault1/clients")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.TagFromHashtags, "tag-from-hashtags", "t", true, "Create tags from inline #tags, slugified like folder tags so #Work and a work/ folder are one tag (Defaults to true)")
+	rootCmd.PersistentFlags().StringVar(&cfg.HashtagPrefix, "hashtag-prefix", "", "Prefix every inline #tag with this namespace")
+	rootCmd.PersistentFlags().StringVar(&cfg.TagPrefixSeparator, "tag-prefix-separator", "/", "Separator between a tag prefix and the tag (Defaults to /)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.TagFromFrontmatter, "tag-from-frontmatter", true, "Create tags from frontmatter tags: with --frontmatter parse (Defaults to true)")
+	rootCmd.PersistentFlags().StringVar(&cfg.TagSeparators, "tag-separator", ",;", "Characters that split a frontmatter tags: value written as one string, e.g. \"work, personal; urgent\" (Defaults to ,;)")
+	rootCmd.PersistentFlags().StringVar(&cfg.TagMapFile, "tag-map", "", "CSV (folder,tag) or JSON ({\"folder\": \"tag\"}) file renaming folder tags; unmapped folders keep their slug")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.TagTransforms, "tag-transform", nil, "Rewrite every extracted tag, before --tag-allow/--tag-deny: lowercase, slugify, strip-prefix=PREFIX or synonym-map=FILE (CSV or JSON of tag -> tag); repeatable, applied in order")
+	rootCmd.PersistentFlags().BoolVar(&cfg.ExpandNestedTags, "expand-nested-tags", false, "Also tag parents of nested #tags (#work/client-a adds work and work/client-a)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.WikilinksAsTags, "wikilinks-as-tags", false, "Create tags from [[wikilink]] targets")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.TagAllow, "tag-allow", nil, "Only keep tags matching this glob; repeatable (when set, the allow-list wins and --tag-deny filters within it)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.TagDeny, "tag-deny", nil, "Drop tags matching this glob (exact names work too); repeatable")
+	rootCmd.PersistentFlags().IntVar(&cfg.MinTagLength, "tag-min-length", 2, "Drop hashtag, folder and frontmatter tags shorter than this many characters, e.g. #a (0 keeps all; Defaults to 2)")
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxTagLength, "max-tag-length", 0, "Longest tag name allowed, in characters (0 means unlimited)")
+	rootCmd.PersistentFlags().StringVar(&cfg.LongTagAction, "long-tag-action", utils.LongTagTruncate, "What to do with tags over --max-tag-length: truncate or skip (Defaults to truncate)")
+	rootCmd.PersistentFlags().StringVar(&cfg.TagsTable, "tags-table", utils.DefaultTagsTable, "Name of the tags table, for schemas that renamed it (Defaults to tags)")
+	rootCmd.PersistentFlags().StringVar(&cfg.TagsNameCol, "tags-name-col", utils.DefaultTagsNameCol, "Name of the tag name column in the tags table (Defaults to name)")
+	rootCmd.PersistentFlags().StringVar(&cfg.NotesContentCol, "notes-content-col", utils.DefaultNotesContentCol, "Name of the note body column in the notes table, e.g. body (Defaults to content)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.ExtraTags, "add-tag", nil, "Add this tag to every imported note, e.g. to mark a migration batch; repeatable")
 
-	rootCmd.MarkPersistentFlagRequired("db")
-	rootCmd.MarkPersistentFlagRequired("root")
+	// Output
+	rootCmd.PersistentFlags().StringVar(&cfg.ReportJSON, "report-json", "", "Write a JSON report of every note and whether it was (or would be) inserted or skipped")
+	rootCmd.PersistentFlags().StringVar(&cfg.ReportCSV, "report-csv", "", "Write the same report as CSV (path, title, tags separated by ;, created, updated, action)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ManifestTable, "manifest-table", "", "Record each run (created_at, root, user_id, files_discovered, notes_inserted, dry_run) as a row in this table, if it exists; a --dry-run only adds it to --emit-sql")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Log per-file details such as resolved tags and timestamps")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log the final summary and errors")
+	rootCmd.PersistentFlags().BoolVar(&showProgress, "progress", false, "Show a progress bar instead of per-file lines (periodic log lines when stderr isn't a terminal)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText, "Log output: text, or json for one object per event with timestamp, level, msg and path (Defaults to text)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored warnings, errors and success lines (also off when stderr isn't a terminal or NO_COLOR is set)")
+	rootCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Import without asking for confirmation (required when stdin isn't a terminal)")
+	rootCmd.MarkFlagsMutuallyExclusive("user-id", "user-email")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "progress")
 }
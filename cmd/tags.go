@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List the tags an import of --root would create, most used first",
+	Long: `List the distinct tags an import of --root would create, without
+connecting to the database.
+
+Discovery runs with the same tag settings as an import, so the output shows
+each tag with the number of notes that would carry it, most used first.
+Useful for spotting junk hashtags (like #1) before they reach Tududi.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "root")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+
+		if _, err := importer.RunTags(cfg, importer.Options{Logger: logger}); err != nil {
+			logger.Fatalf("%v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+}
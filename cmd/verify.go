@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/sottey/tududimport/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check which files under --root were not imported, and which notes have no file",
+	Long: `Check an import after the fact without writing anything.
+
+Every file under --root is matched against the user's notes by title, or by
+source_path when the notes table has it. Files with no matching note and
+notes with no matching file are both listed. Exits with status 1 when any
+file was not imported.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags(cmd, "db", "root")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+
+		rec, err := importer.RunVerify(cfg, importer.Options{Logger: logger})
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		if len(rec.Missing) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -3,8 +3,13 @@ module github.com/sottey/tududimport
 go 1.25.4
 
 require (
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/spf13/cobra v1.10.1
+	github.com/yuin/goldmark v1.8.6
+	golang.org/x/sys v0.38.0
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,48 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// printDiff writes the discovered notes grouped by diff classification, with counts.
+func printDiff(w io.Writer, notes []models.Note, existing map[string]models.Note) {
+	groups := make(map[string][]models.Note)
+	for _, n := range notes {
+		class := utils.ClassifyNote(n, existing)
+		groups[class] = append(groups[class], n)
+	}
+
+	for _, class := range []string{utils.DiffNew, utils.DiffUpdated, utils.DiffDuplicate} {
+		fmt.Fprintf(w, "%s (%d)\n", class, len(groups[class]))
+		for _, n := range groups[class] {
+			fmt.Fprintf(w, "  %s  (%s)\n", n.Title, n.RelPath)
+		}
+	}
+	fmt.Fprintf(w, "\n%d new, %d updated, %d duplicate\n",
+		len(groups[utils.DiffNew]), len(groups[utils.DiffUpdated]), len(groups[utils.DiffDuplicate]))
+}
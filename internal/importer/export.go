@@ -0,0 +1,78 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// RunExport writes each of the user's notes in cfg.DBPath as markdown under
+// cfg.ExportOut, with frontmatter holding its title, tags and dates, in a
+// folder named after its first tag. It returns how many files were written
+// and never writes to the database.
+func RunExport(cfg models.Config, opts Options) (int, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+
+	db, err := connect(&cfg, logger, false)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	notes, err := utils.LoadExportNotes(db, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("load notes: %w", err)
+	}
+	logger.Infof("Exporting %d notes\n", len(notes))
+
+	for i, n := range notes {
+		text, err := utils.NormalizeNote(n, true)
+		if err != nil {
+			return i, fmt.Errorf("export %q: %w", n.Title, err)
+		}
+		dest := filepath.Join(cfg.ExportOut, filepath.FromSlash(n.RelPath))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return i, err
+		}
+		if err := os.WriteFile(dest, []byte(text), 0o644); err != nil {
+			return i, err
+		}
+		if !n.UpdatedAt.IsZero() {
+			if err := os.Chtimes(dest, n.UpdatedAt, n.UpdatedAt); err != nil {
+				return i, err
+			}
+		}
+		logger.WithPath(dest).Debugf("Wrote %s\n", dest)
+	}
+	logger.Infof("Wrote %d notes to %s\n", len(notes), cfg.ExportOut)
+	return len(notes), nil
+}
@@ -0,0 +1,705 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// Options are the RunImport settings that shape output rather than what is imported.
+type Options struct {
+	Logger     *logging.Logger
+	Progress   bool      // draw a progress bar on stderr instead of per-note lines
+	DiffOut    io.Writer // where --diff output goes; nil means os.Stdout
+	PreviewOut io.Writer // where --preview output goes; nil means os.Stdout
+	VerifyOut  io.Writer // where RunVerify's report goes; nil means os.Stdout
+	TagsOut    io.Writer // where RunTags' list goes; nil means os.Stdout
+
+	// Confirm is shown the Plan before anything is written, outside dry-run;
+	// an error aborts the import. nil imports without asking.
+	Confirm func(Plan) error
+}
+
+// Plan describes the writes RunImport is about to make, for Options.Confirm.
+type Plan struct {
+	Notes     int // notes about to be imported
+	Tags      int // distinct tag names across them
+	DBPath    string
+	UserID    int
+	ProjectID int // -1 means none
+
+	// Per-note projects, overriding ProjectID
+	ProjectFromFolder bool   // from each note's top-level folder
+	ProjectMapFile    string // from this folder-to-project map
+}
+
+// connect opens and pings cfg's database and resolves cfg.UserEmail, if set,
+// into cfg.UserID. writes says whether the caller may commit anything, which
+// decides the pragmas set.
+func connect(cfg *models.Config, logger *logging.Logger, writes bool) (*sql.DB, error) {
+	db, err := utils.OpenDB(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	if err := utils.RetryBusy(*cfg, db.Ping); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
+	}
+	pragmas, err := utils.ApplyPragmas(db, *cfg, writes)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("set pragmas: %w", err)
+	}
+
+	logger.Infof("Connected to DB: %s\n", cfg.DBPath)
+	if len(pragmas) > 0 {
+		logger.Debugf("Pragmas: %s\n", strings.Join(pragmas, ", "))
+	}
+
+	if cfg.UserEmail != "" {
+		id, err := utils.ResolveUserID(db, *cfg, cfg.UserEmail)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("resolve --user-email: %w", err)
+		}
+		cfg.UserID = id
+		logger.Infof("Using user %d (%s)\n", id, cfg.UserEmail)
+	}
+	return db, nil
+}
+
+// logWarnings logs discovery warnings, each tagged with its file's path when
+// it has one.
+func logWarnings(logger *logging.Logger, warnings []models.Warning) {
+	for _, w := range warnings {
+		if w.Path == "" {
+			logger.Warnf("%s\n", w.Msg)
+			continue
+		}
+		logger.WithPath(w.Path).Warnf("%s\n", w.Msg)
+	}
+}
+
+// RunImport discovers the notes under each of cfg's roots and writes them to
+// cfg.DBPath (or, with cfg.Diff, compares them against the notes already there,
+// and with cfg.Preview, only prints the first few). Errors are returned rather
+// than exiting; work committed in earlier batches stays committed and is
+// reflected in the returned Summary.
+func RunImport(cfg models.Config, opts Options) (models.Summary, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+	start := time.Now()
+
+	if cfg.DryRunLimit > 0 && !cfg.DryRun && !cfg.Diff && cfg.Preview == 0 {
+		return models.Summary{}, fmt.Errorf("--dry-run-limit needs --dry-run, --diff or --preview")
+	}
+
+	db, err := connect(&cfg, logger, !cfg.DryRun && !cfg.Diff && cfg.Preview == 0)
+	if err != nil {
+		return models.Summary{}, err
+	}
+	defer db.Close()
+
+	if cfg.ProjectMapFile != "" {
+		projectMap, err := utils.LoadProjectMap(cfg.ProjectMapFile)
+		if err != nil {
+			return models.Summary{}, fmt.Errorf("load project map: %w", err)
+		}
+		cfg.ProjectMap = projectMap
+	}
+
+	if err := utils.ValidateSchema(db, cfg); err != nil {
+		return models.Summary{}, fmt.Errorf("validate schema: %w", err)
+	}
+
+	// Optional note columns are only written when the schema has them
+	noteColumns, err := utils.TableColumns(db, cfg, "notes")
+	if err != nil {
+		return models.Summary{}, fmt.Errorf("inspect notes schema: %w", err)
+	}
+	for _, opt := range []struct {
+		enabled *bool
+		column  string
+		flag    string
+	}{
+		{&cfg.RecordSource, "source_path", "--record-source"},
+		{&cfg.Pinned, "pinned", "--pinned"},
+		{&cfg.Archived, "archived", "--archived"},
+		{&cfg.FrontmatterMeta, "metadata", "--frontmatter-to-metadata"},
+	} {
+		if *opt.enabled && !noteColumns[opt.column] {
+			logger.Warnf("notes.%s column not found, ignoring %s\n", opt.column, opt.flag)
+			*opt.enabled = false
+		}
+	}
+	if (cfg.Category != "" || cfg.CategoryFromFolder) && !noteColumns["category"] {
+		logger.Warnf("notes.category column not found, ignoring --category and --category-from-folder\n")
+		cfg.Category, cfg.CategoryFromFolder = "", false
+	}
+	cfg.ContentHash = noteColumns["content_hash"]
+	cfg.Excerpt = noteColumns["excerpt"]
+	if cfg.UpdateChanged {
+		if !noteColumns["source_path"] || !cfg.ContentHash {
+			return models.Summary{}, fmt.Errorf("--update-changed needs notes.source_path and notes.content_hash columns")
+		}
+		cfg.RecordSource = true
+	}
+
+	if cfg.ImportTasks {
+		ok, err := utils.HasColumn(db, cfg, "tasks", "note_id")
+		if err != nil {
+			return models.Summary{}, fmt.Errorf("inspect tasks schema: %w", err)
+		}
+		cfg.TaskNoteID = ok
+	}
+
+	if cfg.ManifestTable != "" {
+		cols, err := utils.TableColumns(db, cfg, cfg.ManifestTable)
+		if err != nil {
+			return models.Summary{}, fmt.Errorf("inspect manifest table: %w", err)
+		}
+		if len(cols) == 0 {
+			logger.Warnf("table %s not found, ignoring --manifest-table\n", cfg.ManifestTable)
+			cfg.ManifestTable = ""
+		}
+	}
+
+	notes, discovered, err := utils.DiscoverNotes(cfg)
+	if err != nil {
+		return models.Summary{}, fmt.Errorf("discover notes: %w", err)
+	}
+	logWarnings(logger, discovered.Warnings)
+	logger.Infof("Discovered %d markdown files\n", len(notes))
+	if discovered.DateFiltered > 0 {
+		logger.Infof("Skipped %d files modified outside the --since/--until window\n", discovered.DateFiltered)
+	}
+
+	resumed := 0
+	if cfg.StateFile != "" {
+		done, err := utils.LoadState(cfg.StateFile)
+		if err != nil {
+			return models.Summary{}, fmt.Errorf("load state file: %w", err)
+		}
+		remaining := notes[:0]
+		for _, n := range notes {
+			if done[n.Path] {
+				resumed++
+				continue
+			}
+			remaining = append(remaining, n)
+		}
+		notes = remaining
+		if resumed > 0 {
+			logger.Infof("Resuming: skipping %d notes already committed per %s\n", resumed, cfg.StateFile)
+		}
+	}
+
+	summary := models.Summary{DiscoveryStats: discovered, Discovered: len(notes) + resumed, NotesSkipped: resumed}
+
+	if cfg.Preview > 0 {
+		out := opts.PreviewOut
+		if out == nil {
+			out = os.Stdout
+		}
+		printPreview(out, notes)
+		summary.Elapsed = time.Since(start)
+		return summary, nil
+	}
+
+	if cfg.Diff {
+		existing, err := utils.LoadExistingNotes(db, cfg)
+		if err != nil {
+			return summary, fmt.Errorf("load existing notes: %w", err)
+		}
+		out := opts.DiffOut
+		if out == nil {
+			out = os.Stdout
+		}
+		printDiff(out, notes, existing)
+		summary.Elapsed = time.Since(start)
+		return summary, nil
+	}
+
+	if opts.Confirm != nil && !cfg.DryRun {
+		tags := make(map[string]bool)
+		for _, n := range notes {
+			for _, t := range n.Tags {
+				tags[t] = true
+			}
+		}
+		plan := Plan{
+			Notes: len(notes), Tags: len(tags), DBPath: cfg.DBPath, UserID: cfg.UserID, ProjectID: cfg.ProjectID,
+			ProjectFromFolder: cfg.ProjectFromFolder, ProjectMapFile: cfg.ProjectMapFile,
+		}
+		if err := opts.Confirm(plan); err != nil {
+			summary.Elapsed = time.Since(start)
+			return summary, err
+		}
+	}
+
+	var dump *utils.SQLDump
+	if cfg.EmitSQL != "" {
+		dump, err = utils.NewSQLDump(cfg.EmitSQL, cfg.Driver)
+		if err != nil {
+			return summary, fmt.Errorf("create SQL dump: %w", err)
+		}
+		defer dump.Close()
+		cfg.SQLRecorder = dump
+	}
+
+	cache := newIDCaches() // committed rows only
+
+	var (
+		tx         *sql.Tx
+		batchCache idCaches // cache plus rows created in the open batch
+		batchNotes int
+		committed  int
+		batchPaths []string // source paths of notes inserted in the open batch
+		usedTags   = make(map[int64]bool)
+		noteIndex  = make(utils.NoteIndex) // for resolving wikilinks once every note has an id
+		linked     []linkSource
+		report     []models.ReportEntry
+	)
+
+	fail := func(format string, args ...interface{}) (models.Summary, error) {
+		summary.Elapsed = time.Since(start)
+		return summary, fmt.Errorf(format+" (%d notes committed before failure)", append(args, committed)...)
+	}
+
+	begin := func() error {
+		err := utils.RetryBusy(cfg, func() error {
+			var err error
+			tx, err = db.Begin()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("begin tx: %w", err)
+		}
+		batchCache = cache.clone()
+		batchNotes = 0
+		batchPaths = batchPaths[:0]
+		return nil
+	}
+
+	// finish commits (or in dry-run rolls back) the open batch. Rows created in
+	// the batch only become visible to later batches once it is committed; a
+	// dry run only calls it at the end, as its batches share one transaction.
+	finish := func() error {
+		if cfg.DryRun {
+			if err := tx.Rollback(); err != nil {
+				return fmt.Errorf("rollback tx: %w", err)
+			}
+			// The dump shows what would have been committed
+			if dump != nil {
+				dump.Commit()
+			}
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit tx: %w", err)
+		}
+		if dump != nil {
+			dump.Commit()
+		}
+		cache = batchCache
+		committed += batchNotes
+		if cfg.StateFile != "" {
+			if err := utils.AppendState(cfg.StateFile, batchPaths); err != nil {
+				return fmt.Errorf("write state file: %w", err)
+			}
+		}
+		return nil
+	}
+
+	var bar *logging.Progress
+	if opts.Progress {
+		bar = logging.NewProgress(os.Stderr, logger, len(notes))
+	}
+
+	if err := begin(); err != nil {
+		return fail("%w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	// importNote writes one note, its tags and its tasks in the open batch.
+	importNote := func(i int, n models.Note) error {
+		skip := func(reason string) {
+			if bar != nil {
+				bar.Increment()
+			} else {
+				logger.WithPath(n.Path).Infof("[%d/%d] Skipping %s (%s)\n", i+1, len(notes), n.Path, reason)
+			}
+			summary.NotesSkipped++
+			report = append(report, utils.NewReportEntry(n, models.ActionSkip))
+		}
+
+		var (
+			existingID int64
+			found      bool
+		)
+		if cfg.UpdateChanged {
+			var hash string
+			existingID, hash, found, err = utils.FindNoteBySource(tx, cfg, n.RelPath)
+			if err != nil {
+				return fmt.Errorf("look up note by source (%s): %w", n.Path, err)
+			}
+			if found && hash == n.ContentHash {
+				skip("unchanged")
+				return nil
+			}
+		}
+		if cfg.SkipExisting && !found {
+			exists, err := utils.NoteExists(tx, cfg, n)
+			if err != nil {
+				return fmt.Errorf("check existing note (%s): %w", n.Path, err)
+			}
+			if exists {
+				skip("already imported")
+				return nil
+			}
+		}
+
+		verb, action := "Importing", models.ActionInsert
+		if found {
+			verb, action = "Updating", models.ActionUpdate
+		}
+		if bar == nil {
+			logger.WithPath(n.Path).Infof("[%d/%d] %s %s\n", i+1, len(notes), verb, n.Path)
+		}
+		logger.WithPath(n.Path).Debugf("    title=%q tags=%v created=%s updated=%s\n", n.Title, utils.UniqueStrings(n.Tags),
+			n.CreatedAt.Format(time.RFC3339), n.UpdatedAt.Format(time.RFC3339))
+
+		noteCfg := cfg
+		noteID := existingID
+		if found {
+			// Updates keep the note's project and tasks as they are; its tags
+			// are unlinked here and relinked from the file below
+			if err := utils.UpdateNote(tx, cfg, noteID, n); err != nil {
+				return fmt.Errorf("update note (%s): %w", n.Path, err)
+			}
+			if err := utils.UnlinkNoteTags(tx, cfg, noteID); err != nil {
+				return fmt.Errorf("unlink tags (%s): %w", n.Path, err)
+			}
+		} else {
+			// A --project-map entry wins over --project-from-folder
+			if id, ok := utils.MappedProject(cfg, n); ok {
+				noteCfg.ProjectID = id
+			} else if cfg.ProjectFromFolder {
+				if folder := utils.TopLevelFolder(n); folder != "" {
+					var areaID int64
+					if cfg.Area != "" {
+						areaID, err = utils.GetOrCreateArea(tx, cfg, batchCache.areas, cfg.Area)
+						if err != nil {
+							return fmt.Errorf("get/create area (%s): %w", cfg.Area, err)
+						}
+					}
+					projectID, err := utils.GetOrCreateProject(tx, cfg, batchCache.projects, folder, areaID)
+					if err != nil {
+						return fmt.Errorf("get/create project (%s): %w", folder, err)
+					}
+					noteCfg.ProjectID = int(projectID)
+				}
+			}
+
+			if cfg.CategoryFromFolder {
+				if folder := utils.TopLevelFolder(n); folder != "" {
+					noteCfg.Category = folder
+				}
+			}
+
+			noteID, err = utils.InsertNote(tx, noteCfg, n)
+			if err != nil {
+				return fmt.Errorf("insert note (%s): %w", n.Path, err)
+			}
+		}
+
+		// Tags only count as used once the note has made it into the batch
+		var noteTags []int64
+		for _, t := range utils.UniqueStrings(n.Tags) {
+			tagID, created, err := utils.GetOrCreateTag(tx, cfg, batchCache.tags, t)
+			if err != nil {
+				return fmt.Errorf("get/create tag (%s): %w", t, err)
+			}
+			if created {
+				summary.TagsCreated++
+			} else if !usedTags[tagID] {
+				summary.TagsReused++
+			}
+			noteTags = append(noteTags, tagID)
+			if err := utils.LinkNoteTag(tx, cfg, noteID, tagID); err != nil {
+				return fmt.Errorf("link note/tag (%d,%d): %w", noteID, tagID, err)
+			}
+			summary.Links++
+		}
+
+		if found {
+			summary.NotesUpdated++
+		} else {
+			for _, task := range n.Tasks {
+				if _, err := utils.InsertTask(tx, noteCfg, noteID, task); err != nil {
+					return fmt.Errorf("insert task (%s): %w", task.Name, err)
+				}
+				summary.TasksInserted++
+			}
+			summary.NotesInserted++
+		}
+
+		for _, id := range noteTags {
+			usedTags[id] = true
+		}
+		if cfg.ImportNoteLinks {
+			noteIndex.Add(n, noteID)
+			if len(n.Links) > 0 {
+				linked = append(linked, linkSource{id: noteID, note: n})
+			}
+		}
+		batchNotes++
+		batchPaths = append(batchPaths, n.Path)
+		if bar != nil {
+			bar.Increment()
+		}
+		report = append(report, utils.NewReportEntry(n, action))
+		return nil
+	}
+
+	// With TxPerNote every note is its own batch; with ContinueOnError each
+	// note runs under a savepoint in the batch. Either way a note that fails
+	// is rolled back and reported instead of ending the run
+	batchSize := cfg.BatchSize
+	if cfg.TxPerNote {
+		batchSize = 1
+	}
+	// A dry run can't roll back its one transaction for a failed note, so it
+	// isolates notes with savepoints even with TxPerNote
+	isolate := cfg.TxPerNote || cfg.ContinueOnError
+	savepoint := isolate && (cfg.DryRun || !cfg.TxPerNote)
+	execSavepoint := func(stmt string) error {
+		return utils.RetryBusy(cfg, func() error {
+			_, err := tx.Exec(stmt)
+			return err
+		})
+	}
+
+	for i, n := range notes {
+		before := summary
+		mark := 0
+		if dump != nil {
+			mark = dump.Savepoint()
+		}
+		if savepoint {
+			if err := execSavepoint("SAVEPOINT tududimport_note"); err != nil {
+				return fail("savepoint: %w", err)
+			}
+		}
+		err := importNote(i, n)
+		if err == nil && savepoint {
+			err = execSavepoint("RELEASE SAVEPOINT tududimport_note")
+		}
+		if err != nil {
+			if !isolate {
+				return fail("%w", err)
+			}
+			logger.WithPath(n.Path).Warnf("failed to import %s, rolled back: %v\n", n.Path, err)
+			summary = before
+			summary.NotesFailed++
+			entry := utils.NewReportEntry(n, models.ActionFail)
+			entry.Error = err.Error()
+			report = append(report, entry)
+			if bar != nil {
+				bar.Increment()
+			}
+			if savepoint {
+				if err := execSavepoint("ROLLBACK TO SAVEPOINT tududimport_note"); err != nil {
+					return fail("roll back to savepoint: %w", err)
+				}
+				if dump != nil {
+					dump.RollbackTo(mark)
+				}
+				if err := execSavepoint("RELEASE SAVEPOINT tududimport_note"); err != nil {
+					return fail("release savepoint: %w", err)
+				}
+				// Ids cached for rows the note created are gone; the rest are looked up again
+				batchCache = cache.clone()
+				continue
+			}
+			if err := tx.Rollback(); err != nil {
+				return fail("rollback tx: %w", err)
+			}
+			if dump != nil {
+				dump.Rollback()
+			}
+			if err := begin(); err != nil {
+				return fail("%w", err)
+			}
+			continue
+		}
+
+		if batchSize > 0 && batchNotes >= batchSize && i < len(notes)-1 {
+			// A dry run keeps its one transaction open across batches, so rows
+			// (and cached ids) from earlier batches stay valid for later ones
+			// and the counts match a real run; it's rolled back at the end
+			if cfg.DryRun {
+				cache = batchCache.clone()
+				batchNotes = 0
+				if !cfg.TxPerNote {
+					logger.Infof("DRY-RUN: end of batch, %d notes processed so far\n", summary.NotesInserted)
+				}
+				continue
+			}
+			if err := finish(); err != nil {
+				return fail("%w", err)
+			}
+			// Per-note commits aren't logged; the per-note lines already show progress
+			if !cfg.TxPerNote {
+				logger.Infof("Committed batch, %d notes committed so far\n", committed)
+			}
+			if err := begin(); err != nil {
+				return fail("%w", err)
+			}
+		}
+	}
+
+	// Wikilinks are resolved in the last batch, once every note has an id
+	for _, src := range linked {
+		for _, target := range src.note.Links {
+			targetID, ok := noteIndex.Resolve(target)
+			if !ok {
+				logger.WithPath(src.note.Path).Warnf("unresolved wikilink [[%s]] in %s\n", target, src.note.Path)
+				summary.Unresolved++
+				continue
+			}
+			if targetID == src.id {
+				continue
+			}
+			if err := utils.LinkNotes(tx, cfg, src.id, targetID); err != nil {
+				return fail("link note %s to [[%s]]: %w", src.note.Path, target, err)
+			}
+			summary.NoteLinks++
+		}
+	}
+
+	if err := finish(); err != nil {
+		return fail("%w", err)
+	}
+	if bar != nil {
+		bar.Finish()
+	}
+
+	// The manifest row gets its own transaction. A dry run writes nothing, so
+	// there the row only reaches the SQL dump, if any.
+	if cfg.ManifestTable != "" && (!cfg.DryRun || dump != nil) {
+		err := utils.RetryBusy(cfg, func() error {
+			var err error
+			tx, err = db.Begin()
+			return err
+		})
+		if err == nil {
+			if err = utils.RecordManifest(tx, cfg, summary); err == nil {
+				if cfg.DryRun {
+					err = tx.Rollback()
+				} else {
+					err = tx.Commit()
+				}
+			}
+		}
+		if err != nil {
+			return fail("record manifest: %w", err)
+		}
+		if dump != nil {
+			dump.Commit()
+		}
+	}
+
+	if dump != nil {
+		if err := dump.Close(); err != nil {
+			summary.Elapsed = time.Since(start)
+			return summary, fmt.Errorf("write SQL dump: %w", err)
+		}
+		logger.Infof("Wrote executed SQL to %s\n", cfg.EmitSQL)
+	}
+	if cfg.ErrorFile != "" {
+		if err := utils.WriteErrorFile(cfg.ErrorFile, report); err != nil {
+			summary.Elapsed = time.Since(start)
+			return summary, fmt.Errorf("write error file: %w", err)
+		}
+		logger.Infof("Wrote %d failed notes to %s\n", summary.NotesFailed, cfg.ErrorFile)
+	}
+	if cfg.ReportJSON != "" {
+		if err := utils.WriteReportJSON(cfg.ReportJSON, report); err != nil {
+			summary.Elapsed = time.Since(start)
+			return summary, fmt.Errorf("write JSON report: %w", err)
+		}
+		logger.Infof("Wrote JSON report to %s\n", cfg.ReportJSON)
+	}
+	if cfg.ReportCSV != "" {
+		if err := utils.WriteReportCSV(cfg.ReportCSV, report); err != nil {
+			summary.Elapsed = time.Since(start)
+			return summary, fmt.Errorf("write CSV report: %w", err)
+		}
+		logger.Infof("Wrote CSV report to %s\n", cfg.ReportCSV)
+	}
+
+	summary.Elapsed = time.Since(start)
+	return summary, nil
+}
+
+// linkSource is an imported note whose wikilinks still need resolving.
+type linkSource struct {
+	id   int64
+	note models.Note
+}
+
+// idCaches holds the row ids resolved so far, each keyed by name|userID.
+type idCaches struct {
+	tags     *utils.IDCache
+	projects *utils.IDCache
+	areas    *utils.IDCache
+}
+
+func newIDCaches() idCaches {
+	return idCaches{
+		tags:     utils.NewIDCache(),
+		projects: utils.NewIDCache(),
+		areas:    utils.NewIDCache(),
+	}
+}
+
+// clone returns an independent copy of the caches.
+func (c idCaches) clone() idCaches {
+	return idCaches{
+		tags:     c.tags.Clone(),
+		projects: c.projects.Clone(),
+		areas:    c.areas.Clone(),
+	}
+}
@@ -0,0 +1,507 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// testSchema is the part of Tududi's schema the importer writes to.
+const testSchema = `
+CREATE TABLE users(id INTEGER PRIMARY KEY, email TEXT);
+CREATE TABLE notes(id INTEGER PRIMARY KEY, uid TEXT, title TEXT, content TEXT, user_id INT, project_id INT, source_path TEXT, content_hash TEXT, created_at TEXT, updated_at TEXT);
+CREATE TABLE tags(id INTEGER PRIMARY KEY, uid TEXT, name TEXT, user_id INT, created_at TEXT, updated_at TEXT);
+CREATE TABLE notes_tags(note_id INT, tag_id INT, created_at TEXT, updated_at TEXT, UNIQUE(note_id, tag_id));
+CREATE TABLE tasks(id INTEGER PRIMARY KEY, uid TEXT, name TEXT, status INT, completed_at TEXT, user_id INT, project_id INT, note_id INT, created_at TEXT, updated_at TEXT);
+INSERT INTO users(id, email) VALUES (1, 'me@example.com');
+`
+
+// newTestDB creates a SQLite database with testSchema and returns its path.
+func newTestDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tududi.db")
+	execSQL(t, path, testSchema)
+	return path
+}
+
+// execSQL runs script against the SQLite database at path.
+func execSQL(t *testing.T, path, script string) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(script); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// queryInt returns the single integer query yields on the database at path.
+func queryInt(t *testing.T, path, query string) int {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// writeTree creates files, keyed by slash path, under a new directory and
+// returns it.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// testConfig is the command's default configuration for importing root
+// into dbPath.
+func testConfig(dbPath, root string) models.Config {
+	return models.Config{
+		Driver:             utils.DriverSQLite,
+		DBPath:             dbPath,
+		Root:               root,
+		UserID:             1,
+		UIDFormat:          utils.UIDShort,
+		ProjectID:          -1,
+		BusyRetries:        5,
+		TagFromFolders:     true,
+		TagFromHashtags:    true,
+		TagFromFrontmatter: true,
+		TagSeparators:      ",;",
+		FlattenSeparator:   "-",
+		TagPrefixSeparator: "/",
+		MinTagLength:       2,
+		LongTagAction:      utils.LongTagTruncate,
+		Format:             utils.FormatMarkdown,
+		Frontmatter:        utils.FrontmatterOff,
+		TitleSources:       utils.DefaultTitleSources,
+		TemplatePosition:   utils.TemplatePrepend,
+		DatePattern:        "2006-01-02",
+		Extensions:         utils.DefaultExtensions,
+		Workers:            2,
+		OversizeAction:     utils.OversizeSkip,
+	}
+}
+
+// sharedTagTree is three notes sharing #shared, with five distinct tags.
+var sharedTagTree = map[string]string{
+	"a.md": "# A\n\n#shared #alpha\n",
+	"b.md": "# B\n\n#shared #beta\n",
+	"c.md": "# C\n\n#shared #gamma #delta\n",
+}
+
+func TestDryRunBatchesMatchUnbatchedCounts(t *testing.T) {
+	root := writeTree(t, sharedTagTree)
+
+	run := func(batchSize int, txPerNote bool) models.Summary {
+		t.Helper()
+		dbPath := newTestDB(t)
+		cfg := testConfig(dbPath, root)
+		cfg.DryRun = true
+		cfg.BatchSize = batchSize
+		cfg.TxPerNote = txPerNote
+		summary, err := RunImport(cfg, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := queryInt(t, dbPath, "SELECT COUNT(*) FROM tags"); n != 0 {
+			t.Errorf("dry run left %d tags behind", n)
+		}
+		return summary
+	}
+
+	want := run(0, false)
+	if want.TagsCreated != 5 || want.Links != 7 || want.NotesInserted != 3 {
+		t.Fatalf("unbatched dry run: created %d tags, %d links, %d notes; want 5, 7, 3", want.TagsCreated, want.Links, want.NotesInserted)
+	}
+	for _, tc := range []struct {
+		name      string
+		batchSize int
+		txPerNote bool
+	}{
+		{"batch size 1", 1, false},
+		{"batch size 2", 2, false},
+		{"tx per note", 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := run(tc.batchSize, tc.txPerNote)
+			if got.TagsCreated != want.TagsCreated || got.TagsReused != want.TagsReused || got.Links != want.Links || got.NotesInserted != want.NotesInserted {
+				t.Errorf("created %d, reused %d, links %d, notes %d; want %d, %d, %d, %d",
+					got.TagsCreated, got.TagsReused, got.Links, got.NotesInserted,
+					want.TagsCreated, want.TagsReused, want.Links, want.NotesInserted)
+			}
+		})
+	}
+}
+
+func TestUpdateChangedReplacesTags(t *testing.T) {
+	root := writeTree(t, map[string]string{"note.md": "# Note\n\n#keep #drop\n"})
+	dbPath := newTestDB(t)
+	cfg := testConfig(dbPath, root)
+	cfg.UpdateChanged = true
+
+	if _, err := RunImport(cfg, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "note.md"), []byte("# Note\n\n#keep #added\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := RunImport(cfg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.NotesUpdated != 1 || summary.NotesInserted != 0 {
+		t.Fatalf("updated %d, inserted %d; want 1, 0", summary.NotesUpdated, summary.NotesInserted)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query(`SELECT t.name FROM notes_tags nt JOIN tags t ON t.id = nt.tag_id ORDER BY t.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var linked []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		linked = append(linked, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"added", "keep"}; !reflect.DeepEqual(linked, want) {
+		t.Errorf("linked tags %q; want %q", linked, want)
+	}
+}
+
+func TestEmitSQLReplaysIntoFreshDB(t *testing.T) {
+	root := writeTree(t, sharedTagTree)
+	dbPath := newTestDB(t)
+	// Existing rows, so the ids the run assigns don't start at 1
+	execSQL(t, dbPath, `
+		INSERT INTO notes(id, uid, title, content, user_id) VALUES (1, 'n1', 'Old', 'old', 1);
+		INSERT INTO tags(id, uid, name, user_id) VALUES (1, 't1', 'old', 1);
+	`)
+	dumpPath := filepath.Join(t.TempDir(), "import.sql")
+	cfg := testConfig(dbPath, root)
+	cfg.DryRun = true
+	cfg.BatchSize = 1
+	cfg.EmitSQL = dumpPath
+	if _, err := RunImport(cfg, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dump, err := os.ReadFile(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := newTestDB(t)
+	execSQL(t, fresh, string(dump))
+
+	if n := queryInt(t, fresh, "SELECT COUNT(*) FROM notes"); n != 3 {
+		t.Errorf("replay created %d notes; want 3", n)
+	}
+	if n := queryInt(t, fresh, "SELECT COUNT(DISTINCT name) FROM tags"); n != 5 {
+		t.Errorf("replay created %d distinct tags; want 5", n)
+	}
+	if n := queryInt(t, fresh, "SELECT COUNT(*) FROM tags"); n != 5 {
+		t.Errorf("replay created %d tag rows; want 5", n)
+	}
+
+	db, err := sql.Open("sqlite3", fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+		SELECT n.title, t.name FROM notes_tags nt
+		JOIN notes n ON n.id = nt.note_id
+		JOIN tags t ON t.id = nt.tag_id
+		ORDER BY n.title, t.name
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var links []string
+	for rows.Next() {
+		var title, tag string
+		if err := rows.Scan(&title, &tag); err != nil {
+			t.Fatal(err)
+		}
+		links = append(links, title+":"+tag)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"A:alpha", "A:shared", "B:beta", "B:shared", "C:delta", "C:gamma", "C:shared"}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("replayed links %q; want %q", links, want)
+	}
+
+	// Replaying onto the source database, where those ids are still free, works too
+	execSQL(t, dbPath, string(dump))
+	if n := queryInt(t, dbPath, "SELECT COUNT(*) FROM notes_tags"); n != 7 {
+		t.Errorf("replay onto the source left %d links; want 7", n)
+	}
+}
+
+func TestEmitSQLLeavesOutRolledBackNotes(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.md":   "# A\n\n#shared\n",
+		"bad.md": "# Bad\n\n#badonly #poison\n",
+		"c.md":   "# C\n\n#shared\n",
+	})
+	dbPath := newTestDB(t)
+	execSQL(t, dbPath, `
+		CREATE TRIGGER poison BEFORE INSERT ON tags WHEN NEW.name = 'poison'
+		BEGIN SELECT RAISE(ABORT, 'poisoned tag'); END;
+	`)
+	dumpPath := filepath.Join(t.TempDir(), "import.sql")
+	for _, tc := range []struct {
+		name string
+		set  func(*models.Config)
+	}{
+		{"continue on error", func(c *models.Config) { c.ContinueOnError = true }},
+		{"tx per note", func(c *models.Config) { c.TxPerNote = true }},
+		{"tx per note dry run", func(c *models.Config) { c.TxPerNote, c.DryRun = true, true }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := filepath.Join(t.TempDir(), "tududi.db")
+			src, err := os.ReadFile(dbPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(db, src, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg := testConfig(db, root)
+			cfg.EmitSQL = dumpPath
+			tc.set(&cfg)
+			summary, err := RunImport(cfg, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.NotesFailed != 1 || summary.NotesInserted != 2 {
+				t.Fatalf("failed %d, inserted %d; want 1, 2", summary.NotesFailed, summary.NotesInserted)
+			}
+
+			dump, err := os.ReadFile(dumpPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(dump), "badonly") || strings.Contains(string(dump), "'Bad'") {
+				t.Errorf("dump has statements of the rolled-back note:\n%s", dump)
+			}
+			fresh := newTestDB(t)
+			execSQL(t, fresh, string(dump))
+			if n := queryInt(t, fresh, "SELECT COUNT(*) FROM notes"); n != 2 {
+				t.Errorf("replay created %d notes; want 2", n)
+			}
+			if n := queryInt(t, fresh, "SELECT COUNT(*) FROM notes_tags"); n != 2 {
+				t.Errorf("replay created %d links; want 2", n)
+			}
+		})
+	}
+}
+
+func TestFolderAndHashtagLinkOnce(t *testing.T) {
+	root := writeTree(t, map[string]string{"Work/note.md": "# Note\n\n#work and #WORK again\n"})
+	dbPath := newTestDB(t)
+	summary, err := RunImport(testConfig(dbPath, root), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TagsCreated != 1 || summary.Links != 1 {
+		t.Errorf("created %d tags, %d links; want 1, 1", summary.TagsCreated, summary.Links)
+	}
+	if n := queryInt(t, dbPath, "SELECT COUNT(*) FROM notes_tags"); n != 1 {
+		t.Errorf("%d notes_tags rows; want 1", n)
+	}
+}
+
+func TestDryRunLeavesJournalMode(t *testing.T) {
+	root := writeTree(t, sharedTagTree)
+	journalMode := func(path string) string {
+		t.Helper()
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		var mode string
+		if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+			t.Fatal(err)
+		}
+		return mode
+	}
+
+	for _, tc := range []struct {
+		name string
+		set  func(*models.Config)
+		want string
+	}{
+		{"dry run", func(c *models.Config) { c.DryRun = true }, "delete"},
+		{"diff", func(c *models.Config) { c.Diff = true }, "delete"},
+		{"preview", func(c *models.Config) { c.Preview = 1 }, "delete"},
+		{"import", func(c *models.Config) {}, "wal"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dbPath := newTestDB(t)
+			cfg := testConfig(dbPath, root)
+			tc.set(&cfg)
+			if _, err := RunImport(cfg, Options{DiffOut: io.Discard, PreviewOut: io.Discard}); err != nil {
+				t.Fatal(err)
+			}
+			if got := journalMode(dbPath); got != tc.want {
+				t.Errorf("journal_mode %q; want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		dbPath := newTestDB(t)
+		if _, err := RunVerify(testConfig(dbPath, root), Options{VerifyOut: io.Discard}); err != nil {
+			t.Fatal(err)
+		}
+		if got := journalMode(dbPath); got != "delete" {
+			t.Errorf("journal_mode %q; want %q", got, "delete")
+		}
+	})
+}
+
+func TestDiscoveryWarningsCarryPath(t *testing.T) {
+	dbPath := newTestDB(t)
+	root := writeTree(t, map[string]string{"empty.md": "\n", "full.md": "# Full\n"})
+	cfg := testConfig(dbPath, root)
+	cfg.DryRun = true
+	cfg.SkipEmpty = true
+	var buf bytes.Buffer
+	if _, err := RunImport(cfg, Options{Logger: logging.NewJSON(&buf, logging.LevelNormal)}); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(root, "empty.md")
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event struct{ Level, Msg, Path string }
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if event.Level == "WARN" && strings.Contains(event.Msg, "empty note") {
+			if event.Path != want {
+				t.Errorf("warning path %q; want %q", event.Path, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no empty-note warning in %s", buf.String())
+}
+
+func TestConfirmPlanCarriesProjectSource(t *testing.T) {
+	dbPath := newTestDB(t)
+	execSQL(t, dbPath, "CREATE TABLE projects(id INTEGER PRIMARY KEY, uid TEXT, name TEXT, user_id INTEGER, area_id INTEGER, created_at TEXT, updated_at TEXT)")
+	cfg := testConfig(dbPath, writeTree(t, sharedTagTree))
+	cfg.ProjectFromFolder = true
+	var plan Plan
+	_, err := RunImport(cfg, Options{Confirm: func(p Plan) error {
+		plan = p
+		return io.EOF
+	}})
+	if err != io.EOF {
+		t.Fatalf("err %v; want the Confirm error", err)
+	}
+	if plan.Notes != 3 || plan.Tags != 5 || !plan.ProjectFromFolder || plan.ProjectMapFile != "" {
+		t.Errorf("plan %+v; want 3 notes, 5 tags, projects from folders", plan)
+	}
+	if n := queryInt(t, dbPath, "SELECT COUNT(*) FROM notes"); n != 0 {
+		t.Errorf("%d notes written after a refused confirmation", n)
+	}
+}
+
+func TestDryRunLeavesManifestToDump(t *testing.T) {
+	const manifest = "CREATE TABLE import_runs(id INTEGER PRIMARY KEY, created_at TEXT, root TEXT, user_id INTEGER, files_discovered INTEGER, notes_inserted INTEGER, dry_run BOOLEAN)"
+	root := writeTree(t, sharedTagTree)
+	for _, dryRun := range []bool{true, false} {
+		dbPath := newTestDB(t)
+		execSQL(t, dbPath, manifest)
+		dumpPath := filepath.Join(t.TempDir(), "import.sql")
+		cfg := testConfig(dbPath, root)
+		cfg.DryRun = dryRun
+		cfg.ManifestTable = "import_runs"
+		cfg.EmitSQL = dumpPath
+		if _, err := RunImport(cfg, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		want := 1
+		if dryRun {
+			want = 0
+		}
+		if n := queryInt(t, dbPath, "SELECT COUNT(*) FROM import_runs"); n != want {
+			t.Errorf("dry run %v: %d manifest rows; want %d", dryRun, n, want)
+		}
+
+		dump, err := os.ReadFile(dumpPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fresh := newTestDB(t)
+		execSQL(t, fresh, manifest)
+		execSQL(t, fresh, string(dump))
+		if n := queryInt(t, fresh, "SELECT COUNT(*) FROM import_runs"); n != 1 {
+			t.Errorf("dry run %v: dump replays %d manifest rows; want 1", dryRun, n)
+		}
+	}
+}
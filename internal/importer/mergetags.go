@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// RunMergeTags folds together the user's tags in cfg.DBPath whose names
+// slugify to the same value, repointing their links to the oldest of each
+// group and deleting the rest, all in one transaction. With cfg.DryRun the
+// transaction is rolled back.
+func RunMergeTags(cfg models.Config, opts Options) ([]models.TagMerge, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+
+	db, err := connect(&cfg, logger, !cfg.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var linkTables []string
+	for _, table := range utils.TagLinkTables {
+		cols, err := utils.TableColumns(db, cfg, table)
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s schema: %w", table, err)
+		}
+		if len(cols) > 0 {
+			linkTables = append(linkTables, table)
+		}
+	}
+
+	merges, err := utils.FindDuplicateTags(db, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("load tags: %w", err)
+	}
+	if len(merges) == 0 {
+		logger.Infof("No duplicate tags found\n")
+		return nil, nil
+	}
+
+	var tx *sql.Tx
+	err = utils.RetryBusy(cfg, func() error {
+		var err error
+		tx, err = db.Begin()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range merges {
+		m := &merges[i]
+		if err := utils.MergeTags(tx, cfg, linkTables, m); err != nil {
+			return nil, fmt.Errorf("merge tag %q: %w", m.Kept, err)
+		}
+		logger.Infof("Merged %s into %q (%d links repointed, %d duplicate links removed)\n",
+			quoteAll(m.Merged), m.Kept, m.Repointed, m.Dropped)
+	}
+
+	if cfg.DryRun {
+		return merges, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return merges, nil
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// RunNormalize discovers the notes under cfg's roots and writes each one as
+// normalized markdown to the same relative path under cfg.NormalizeOut,
+// returning how many files were written. It never opens the database.
+func RunNormalize(cfg models.Config, opts Options) (int, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+
+	out, err := filepath.Abs(cfg.NormalizeOut)
+	if err != nil {
+		return 0, err
+	}
+	for _, root := range utils.RootsOf(cfg) {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return 0, err
+		}
+		if out == abs || strings.HasPrefix(out, abs+string(filepath.Separator)) {
+			return 0, fmt.Errorf("--out %s is inside --root %s; pick a separate directory", cfg.NormalizeOut, root)
+		}
+	}
+
+	// Bodies are written as markdown, whatever an import would store
+	cfg.RenderHTML = false
+	// A rewritten block replaces the old one, which mustn't stay in the body
+	if cfg.RewriteFrontmatter && cfg.Frontmatter == utils.FrontmatterOff {
+		cfg.Frontmatter = utils.FrontmatterParse
+	}
+	notes, discovered, err := utils.DiscoverNotes(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("discover notes: %w", err)
+	}
+	logWarnings(logger, discovered.Warnings)
+	logger.Infof("Discovered %d markdown files\n", len(notes))
+
+	written := 0
+	seen := make(map[string]bool)
+	for _, n := range notes {
+		rel := filepath.Clean(n.RelPath)
+		if seen[rel] {
+			logger.WithPath(n.Path).Warnf("skipping %s: %s already written from another root\n", n.Path, rel)
+			continue
+		}
+		seen[rel] = true
+
+		text, err := utils.NormalizeNote(n, cfg.RewriteFrontmatter)
+		if err != nil {
+			return written, fmt.Errorf("normalize %s: %w", n.Path, err)
+		}
+		dest := filepath.Join(out, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(dest, []byte(text), 0o644); err != nil {
+			return written, err
+		}
+		// Keep the note's dates, so importing the output tree gives the same ones
+		if err := os.Chtimes(dest, n.UpdatedAt, n.UpdatedAt); err != nil {
+			return written, err
+		}
+		logger.WithPath(n.Path).Debugf("Wrote %s\n", dest)
+		written++
+	}
+	logger.Infof("Wrote %d notes to %s\n", written, cfg.NormalizeOut)
+	return written, nil
+}
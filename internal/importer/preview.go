@@ -0,0 +1,57 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// printPreview writes the resolved title, tags, dates and counts of each note.
+func printPreview(w io.Writer, notes []models.Note) {
+	for i, n := range notes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		tags := "(none)"
+		if len(n.Tags) > 0 {
+			tags = strings.Join(n.Tags, ", ")
+		}
+		fmt.Fprintf(w, "%s\n", n.RelPath)
+		fmt.Fprintf(w, "  title:   %s\n", n.Title)
+		fmt.Fprintf(w, "  tags:    %s\n", tags)
+		fmt.Fprintf(w, "  created: %s\n", n.CreatedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "  updated: %s\n", n.UpdatedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "  body:    %d bytes\n", len(n.Body))
+		if len(n.Tasks) > 0 {
+			fmt.Fprintf(w, "  tasks:   %d\n", len(n.Tasks))
+		}
+		if len(n.Links) > 0 {
+			fmt.Fprintf(w, "  links:   %s\n", strings.Join(n.Links, ", "))
+		}
+	}
+	fmt.Fprintf(w, "\nPreviewed %d notes, nothing written\n", len(notes))
+}
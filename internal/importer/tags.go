@@ -0,0 +1,65 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// RunTags discovers the notes under cfg's roots and lists the distinct tags
+// an import would create, with how many notes carry each. It never opens
+// the database.
+func RunTags(cfg models.Config, opts Options) ([]models.TagCount, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+
+	notes, discovered, err := utils.DiscoverNotes(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("discover notes: %w", err)
+	}
+	logWarnings(logger, discovered.Warnings)
+	logger.Infof("Discovered %d markdown files\n", len(notes))
+
+	tags := utils.CountTags(notes)
+	out := opts.TagsOut
+	if out == nil {
+		out = os.Stdout
+	}
+	printTagCounts(out, tags)
+	return tags, nil
+}
+
+// printTagCounts writes one "count  tag" line per tag, then the total.
+func printTagCounts(w io.Writer, tags []models.TagCount) {
+	for _, t := range tags {
+		fmt.Fprintf(w, "%6d  %s\n", t.Notes, t.Name)
+	}
+	fmt.Fprintf(w, "\n%d distinct tags\n", len(tags))
+}
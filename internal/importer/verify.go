@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sottey/tududimport/internal/logging"
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/sottey/tududimport/internal/utils"
+)
+
+// RunVerify discovers the notes under cfg's roots and reports, without
+// writing, which files have no matching note in cfg.DBPath and which of the
+// user's notes match no file. A file matches a note with the same title, or
+// with its path in source_path when that column exists.
+func RunVerify(cfg models.Config, opts Options) (models.Reconciliation, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.New(io.Discard, logging.LevelQuiet)
+	}
+
+	db, err := connect(&cfg, logger, false)
+	if err != nil {
+		return models.Reconciliation{}, err
+	}
+	defer db.Close()
+
+	withSource, err := utils.HasColumn(db, cfg, "notes", "source_path")
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("inspect notes schema: %w", err)
+	}
+	refs, err := utils.LoadNoteRefs(db, cfg, withSource)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("load existing notes: %w", err)
+	}
+
+	notes, discovered, err := utils.DiscoverNotes(cfg)
+	if err != nil {
+		return models.Reconciliation{}, fmt.Errorf("discover notes: %w", err)
+	}
+	logWarnings(logger, discovered.Warnings)
+	logger.Infof("Discovered %d markdown files, %d notes in the database\n", len(notes), len(refs))
+
+	rec := utils.Reconcile(notes, refs)
+	out := opts.VerifyOut
+	if out == nil {
+		out = os.Stdout
+	}
+	printReconciliation(out, rec)
+	return rec, nil
+}
+
+// printReconciliation writes both directions of rec, with counts.
+func printReconciliation(w io.Writer, rec models.Reconciliation) {
+	fmt.Fprintf(w, "FILES NOT IMPORTED (%d)\n", len(rec.Missing))
+	for _, n := range rec.Missing {
+		fmt.Fprintf(w, "  %s  (%s)\n", n.RelPath, n.Title)
+	}
+	fmt.Fprintf(w, "NOTES WITHOUT A FILE (%d)\n", len(rec.Orphaned))
+	for _, r := range rec.Orphaned {
+		if r.SourcePath != "" {
+			fmt.Fprintf(w, "  #%d %s  (%s)\n", r.ID, r.Title, r.SourcePath)
+		} else {
+			fmt.Fprintf(w, "  #%d %s\n", r.ID, r.Title)
+		}
+	}
+	fmt.Fprintf(w, "\n%d matched, %d not imported, %d without a file\n", rec.Matched, len(rec.Missing), len(rec.Orphaned))
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Level int
+
+const (
+	LevelQuiet   Level = iota // final summary and errors only
+	LevelNormal               // plus warnings and per-file progress
+	LevelVerbose              // plus per-file details
+)
+
+// Supported --log-format values
+const (
+	FormatText = "text" // "2006/01/02 15:04:05 message" lines
+	FormatJSON = "json" // one JSON object per event: timestamp, level, msg and, for per-file events, path
+)
+
+// ValidateFormat rejects unsupported --log-format values.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported log format %q (want %s or %s)", format, FormatText, FormatJSON)
+}
+
+// ANSI colors used when color is enabled
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// Logger is a small leveled wrapper around a slog.Logger, which writes
+// either text lines or JSON events.
+type Logger struct {
+	out   *slog.Logger
+	level Level
+	json  bool
+	color bool // paint warnings, errors and success lines
+}
+
+// New returns a Logger writing text lines to w at the given level.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: slog.New(&textHandler{w: w, mu: new(sync.Mutex)}), level: level}
+}
+
+// NewJSON returns a Logger writing one JSON object per event to w at the
+// given level. Summary and success lines carry a kind attribute.
+func NewJSON(w io.Writer, level Level) *Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug, // Logger does its own level filtering
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				a.Key = "timestamp"
+			}
+			return a
+		},
+	})
+	return &Logger{out: slog.New(h), level: level, json: true}
+}
+
+// JSON reports whether the logger writes JSON events.
+func (l *Logger) JSON() bool {
+	return l.json
+}
+
+// WithPath returns a logger whose events carry path as their path attribute.
+// Text output is unchanged.
+func (l *Logger) WithPath(path string) *Logger {
+	c := *l
+	c.out = l.out.With("path", path)
+	return &c
+}
+
+// SetColor turns ANSI colors on or off: yellow warnings, red errors and a
+// green success line. Off by default, and never used for JSON.
+func (l *Logger) SetColor(on bool) {
+	l.color = on && !l.json
+}
+
+// paint wraps msg, minus its trailing newline, in color when enabled.
+func (l *Logger) paint(color, msg string) string {
+	if !l.color {
+		return msg
+	}
+	text := strings.TrimRight(msg, "\n")
+	return color + text + colorReset + msg[len(text):]
+}
+
+// emit hands msg to the slog logger. JSON messages lose their trailing
+// newline and surrounding indentation.
+func (l *Logger) emit(level slog.Level, msg string, args ...interface{}) {
+	if l.json {
+		msg = strings.TrimSpace(msg)
+	}
+	l.out.Log(context.Background(), level, msg, args...)
+}
+
+// Successf logs that the run finished, in green, shown at every level.
+func (l *Logger) Successf(format string, args ...interface{}) {
+	l.emit(slog.LevelInfo, l.paint(colorGreen, fmt.Sprintf(format, args...)), "kind", "success")
+}
+
+// Summaryf logs final results, shown at every level.
+func (l *Logger) Summaryf(format string, args ...interface{}) {
+	l.emit(slog.LevelInfo, fmt.Sprintf(format, args...), "kind", "summary")
+}
+
+// Warnf logs a warning, hidden by --quiet.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if l.level >= LevelNormal {
+		prefix := "WARNING: "
+		if l.json {
+			prefix = ""
+		}
+		l.emit(slog.LevelWarn, l.paint(colorYellow, fmt.Sprintf(prefix+format, args...)))
+	}
+}
+
+// Infof logs progress, hidden by --quiet.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if l.level >= LevelNormal {
+		l.emit(slog.LevelInfo, fmt.Sprintf(format, args...))
+	}
+}
+
+// Debugf logs details, shown only with --verbose.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.level >= LevelVerbose {
+		l.emit(slog.LevelDebug, fmt.Sprintf(format, args...))
+	}
+}
+
+// Fatalf logs an error at every level and exits.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.emit(slog.LevelError, l.paint(colorRed, fmt.Sprintf(format, args...)))
+	os.Exit(1)
+}
+
+// textHandler writes records as the standard log package would with
+// log.LstdFlags: a local timestamp, the message and a newline. Attributes
+// are dropped.
+type textHandler struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (h *textHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	line := r.Time.Format("2006/01/02 15:04:05") + " " + r.Message
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line)
+	return err
+}
+
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *textHandler) WithGroup(string) slog.Handler { return h }
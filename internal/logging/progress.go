@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const progressWidth = 30
+
+// Progress renders a single-line progress bar on a terminal, or logs every
+// 10% through a Logger when the output isn't a terminal.
+type Progress struct {
+	out      *os.File
+	logger   *Logger
+	tty      bool
+	total    int
+	done     int
+	start    time.Time
+	lastDraw time.Time
+	drawn    int // done count last drawn
+	lastPct  int
+}
+
+// NewProgress starts a progress display for total items on out. A JSON
+// logger always gets the periodic log lines.
+func NewProgress(out *os.File, logger *Logger, total int) *Progress {
+	return &Progress{
+		out:    out,
+		logger: logger,
+		tty:    IsTerminal(out) && !logger.JSON(),
+		total:  total,
+		start:  time.Now(),
+	}
+}
+
+// IsTerminal reports whether f is a character device such as a TTY.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Increment marks one more item as processed.
+func (p *Progress) Increment() {
+	p.done++
+	if p.tty {
+		if time.Since(p.lastDraw) >= 100*time.Millisecond || p.done == p.total {
+			p.draw()
+		}
+		return
+	}
+	if pct := p.percent(); pct/10 > p.lastPct/10 || p.done == p.total {
+		p.lastPct = pct
+		p.logger.Infof("Progress: %d/%d (%d%%) ETA %s\n", p.done, p.total, pct, p.eta())
+	}
+}
+
+// Finish ends the bar's line so later log output starts cleanly.
+func (p *Progress) Finish() {
+	if p.tty {
+		if p.drawn != p.done || p.done == 0 {
+			p.draw()
+		}
+		fmt.Fprintln(p.out)
+	}
+}
+
+func (p *Progress) draw() {
+	p.lastDraw = time.Now()
+	p.drawn = p.done
+	filled := 0
+	if p.total > 0 {
+		filled = progressWidth * p.done / p.total
+	}
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+	fmt.Fprintf(p.out, "\r[%s] %d/%d %3d%% ETA %s ", bar, p.done, p.total, p.percent(), p.eta())
+}
+
+func (p *Progress) percent() int {
+	if p.total == 0 {
+		return 100
+	}
+	return 100 * p.done / p.total
+}
+
+func (p *Progress) eta() time.Duration {
+	if p.done == 0 || p.done >= p.total {
+		return 0
+	}
+	elapsed := time.Since(p.start)
+	return (elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)).Round(time.Second)
+}
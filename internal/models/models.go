@@ -21,23 +21,252 @@ THE SOFTWARE.
 */
 package models
 
-import "time"
+import (
+	"text/template"
+	"time"
+)
 
 type Config struct {
-	DBPath          string
-	Root            string
-	UserID          int
-	ProjectID       int // -1 means NULL / no project
-	DryRun          bool
-	TagFromFolders  bool
-	TagFromHashtags bool
+	Driver             string   // sqlite3 or postgres
+	DBPath             string   // SQLite file path or Postgres connection string
+	Root               string   // the root being discovered; the first of Roots otherwise
+	Roots              []string // every root to import from; empty means just Root
+	UserID             int
+	UserEmail          string // resolved to UserID at startup when set
+	UIDFormat          string // short, uuid or nanoid
+	ProjectID          int    // -1 means NULL / no project
+	ProjectFromFolder  bool
+	ProjectMapFile     string         // CSV or JSON of folder-path -> project id
+	ProjectMap         map[string]int // loaded from ProjectMapFile by RunImport
+	Area               string         // area for projects created from folders
+	Category           string         // notes.category for every note, when the column exists
+	CategoryFromFolder bool           // notes.category from the top-level folder, falling back to Category
+	DryRun             bool
+	Diff               bool          // read-only comparison against existing notes instead of importing
+	Preview            int           // print the first N discovered notes instead of importing; 0 means off
+	DryRunLimit        int           // stop walking after N markdown files, so discovery isn't exhaustive; 0 means off
+	BatchSize          int           // notes per transaction; 0 means one transaction for the whole run
+	TxPerNote          bool          // commit each note on its own and carry on past notes that fail
+	ContinueOnError    bool          // roll back just the failing note (via a savepoint) and carry on
+	ErrorFile          string        // CSV of path,error for each note that failed; empty disables it
+	BusyRetries        int           // retries of a statement that hit a locked database
+	BusyTimeout        time.Duration // SQLite busy_timeout: how long a statement waits for a lock
+	Pragmas            []string      // SQLite key=value pragmas applied on connect, after the defaults
+	StateFile          string        // newline-delimited source paths already committed
+	TagFromFolders     bool
+	FolderTagDepth     int // only tag the first N folders under Root; 0 means all
+	TagFromHashtags    bool
+	TagFromFrontmatter bool
+	TagSeparators      string            // characters that split a single-string frontmatter tags: value
+	FlattenFolderTags  bool              // join the folder slugs into one tag instead of one tag each
+	FlattenSeparator   string            // between slugs of a flattened folder tag
+	FolderTagPrefix    string            // namespace for folder tags, e.g. vault1 -> vault1/clients
+	HashtagPrefix      string            // namespace for inline #tags
+	TagPrefixSeparator string            // between a prefix and the tag
+	ExpandNestedTags   bool              // #a/b also adds its parent tag #a
+	TagMapFile         string            // CSV or JSON of folder-slug -> tag-name
+	TagMap             map[string]string // loaded from TagMapFile by DiscoverNotes
+	TagTransforms      []string          // --tag-transform specs applied in order to every extracted tag
+	TagChain           []TagTransform    // built from TagTransforms by DiscoverNotes, unless already provided
+	WikilinksAsTags    bool
+	TagsTable          string               // tag table name; empty means "tags"
+	TagsNameCol        string               // tag name column; empty means "name"
+	NotesContentCol    string               // note body column; empty means "content"
+	TagAllow           []string             // glob patterns; when set only matching tags are kept
+	TagDeny            []string             // glob patterns of tags to drop
+	MinTagLength       int                  // characters; shorter tags are dropped, 0 or 1 keeps all
+	MaxTagLength       int                  // characters; 0 means unlimited
+	LongTagAction      string               // truncate or skip tags over MaxTagLength
+	ExtraTags          []string             // added to every note
+	Format             string               // markdown, logseq or mmd
+	StripProperties    bool                 // with logseq, drop the page property lines from Body
+	Frontmatter        string               // off (the default), strip or parse
+	FrontmatterMeta    bool                 // with parse, keep unrecognized frontmatter fields as JSON in notes.metadata
+	TitleSources       []string             // ordered title fallback chain: frontmatter, h1, h2, dataview, aliases, filename
+	TitleMaxLength     int                  // truncate longer titles on a word boundary; 0 means unlimited
+	StripTitleMarkdown bool                 // remove emphasis, code and link syntax from the resolved title
+	StripTitleHeading  bool                 // drop the heading line used as the title from Body
+	CollapseBlankLines bool                 // squash runs of 3+ blank lines outside code fences into one
+	BodyTemplate       string               // text/template over the Note fields added to each body; empty disables it
+	BodyTmpl           *template.Template   // parsed from BodyTemplate by DiscoverNotes, unless already provided
+	TemplatePosition   string               // prepend or append the rendered BodyTemplate
+	AttachmentsBase    string               // URL that relative image/attachment links are rewritten under
+	RenderHTML         bool                 // store Body rendered to HTML instead of markdown
+	HTMLExtensions     []string             // goldmark extensions for RenderHTML: table, strikethrough, tasklist, linkify, gfm
+	Renderer           Renderer             // set by DiscoverNotes when RenderHTML is on, unless already provided
+	DatesFromGit       bool                 // created/updated from the first/last commit touching each file
+	GitDates           map[string]FileDates // loaded for DatesFromGit by DiscoverNotes, keyed by slash path under Root
+	DateFromFilename   bool
+	DatePattern        string    // Go time layout matched against the start of the file name
+	Exclude            []string  // glob patterns relative to Root
+	Include            []string  // glob patterns relative to Root; empty means all
+	Extensions         []string  // markdown file extensions without the dot; empty means md
+	FilesFrom          string    // newline-delimited list of files to import instead of walking; "-" reads stdin
+	Files              []string  // the absolute paths from FilesFrom under Root, set by DiscoverNotes
+	Since              time.Time // only files modified at or after this; zero means no bound
+	Until              time.Time // only files modified at or before this; zero means no bound
+	FixedCreated       time.Time // created_at for every note in place of file, git and filename dates; zero means off
+	FixedUpdated       time.Time // updated_at likewise
+	RespectGitignore   bool
+	FollowSymlinks     bool
+	Workers            int    // concurrent markdown parsers
+	MaxBodySize        int64  // bytes; 0 means unlimited
+	OversizeAction     string // skip or truncate files over MaxBodySize
+	SkipEmpty          bool   // drop notes whose body is blank
+	SkipTitleOnly      bool   // with SkipEmpty, also drop notes holding only a heading
+	OnlyTagged         bool   // drop notes left with no tags once tag extraction and filtering are done
+	SkipDrafts         bool   // with frontmatter parse, drop notes with draft: true or publish: false
+	SkipExisting       bool
+	FailOnError        bool        // fail after discovery if any file couldn't be read or parsed
+	DedupeNotes        string      // "", "merge", "folder" or "number" for notes sharing a title
+	ManifestTable      string      // table that gets one audit row per run, if it exists
+	ReportJSON         string      // path of the JSON import report; empty disables it
+	ReportCSV          string      // path of the CSV import report; empty disables it
+	EmitSQL            string      // path of a .sql file receiving every write statement; empty disables it
+	NormalizeOut       string      // output tree of the normalize subcommand
+	RewriteFrontmatter bool        // with normalize, write a fresh frontmatter block per note
+	ExportOut          string      // output tree of the export subcommand
+	SQLRecorder        SQLRecorder // set by RunImport when EmitSQL is set
+	RecordSource       bool        // write RelPath into notes.source_path when the column exists
+	Pinned             bool        // set notes.pinned when the column exists
+	Archived           bool        // set notes.archived when the column exists
+	ImportTasks        bool
+	ImportNoteLinks    bool // resolve [[wikilinks]] between imported notes into note_links
+	UpdateChanged      bool // update notes whose source_path matches but content_hash differs
+	ContentHash        bool // set at startup when notes has a content_hash column
+	Excerpt            bool // set at startup when notes has an excerpt column
+	TaskNoteID         bool // set at startup when tasks has a note_id column
+}
+
+// Renderer converts a parsed markdown body into the stored content.
+type Renderer interface {
+	Render(markdown string) (string, error)
+}
+
+// TagTransform rewrites one extracted tag into zero or more tags.
+type TagTransform func(tag string) []string
+
+// SQLRecorder receives each write statement the importer executes, with ?
+// placeholders and its arguments.
+type SQLRecorder interface {
+	Record(query string, args ...interface{})
 }
 
 type Note struct {
-	Title     string
-	Body      string
-	Tags      []string
-	Path      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	Title       string
+	Body        string
+	Tags        []string
+	Tasks       []Task
+	Links       []string // [[wikilink]] targets, collected for ImportNoteLinks
+	Metadata    string   // JSON of unrecognized frontmatter fields, for FrontmatterMeta
+	Path        string
+	RelPath     string // Path relative to Config.Root
+	ContentHash string // SHA-256 of the parsed body, before any template or rendering
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// FileDates are a file's creation and last-modification times.
+type FileDates struct {
+	Created time.Time
+	Updated time.Time
+}
+
+// Task is a markdown checkbox item ("- [ ] ..." / "- [x] ...").
+type Task struct {
+	Name      string
+	Completed bool
+}
+
+// DiscoveryStats counts notes that discovery dropped or changed.
+type DiscoveryStats struct {
+	Deduped      int         // notes merged into another, or retitled, by DedupeNotes
+	DateFiltered int         // files outside the Since/Until window
+	Empty        int         // blank notes dropped by SkipEmpty
+	Untagged     int         // notes without tags dropped by OnlyTagged
+	Drafts       int         // notes dropped by SkipDrafts
+	Limited      bool        // the walk stopped early at DryRunLimit
+	Files        int         // markdown files picked up for parsing, counted against DryRunLimit
+	Warnings     []Warning   // non-fatal problems found while walking
+	Roots        []RootCount // notes found under each root, when there are several
+	FileErrors   []string    // "path: reason" for each file that couldn't be read or parsed
+}
+
+// Warning is a non-fatal problem found during discovery.
+type Warning struct {
+	Path string // the file it concerns, or "" for the run as a whole
+	Msg  string
+}
+
+// RootCount is how many notes discovery kept from one root.
+type RootCount struct {
+	Root  string
+	Notes int
+}
+
+// NoteRef identifies a note already in the database.
+type NoteRef struct {
+	ID         int64
+	Title      string
+	SourcePath string // empty when unknown or the column doesn't exist
+}
+
+// Reconciliation compares discovered files with the notes in the database.
+type Reconciliation struct {
+	Matched  int       // files with a matching note
+	Missing  []Note    // files with no matching note
+	Orphaned []NoteRef // notes with no matching file
+}
+
+// TagCount is a tag and the number of notes carrying it.
+type TagCount struct {
+	Name  string
+	Notes int
+}
+
+// TagMerge is a set of a user's tags whose names slugify to the same value,
+// folded into the oldest of them.
+type TagMerge struct {
+	KeptID    int64
+	Kept      string
+	MergedIDs []int64
+	Merged    []string
+	Repointed int // links moved to the kept tag
+	Dropped   int // links removed because the note already had the kept tag
+}
+
+// Summary counts what an import run did (or would do in dry-run).
+type Summary struct {
+	DiscoveryStats
+	Discovered    int // notes found, including those skipped via the state file
+	NotesInserted int
+	NotesUpdated  int
+	NotesSkipped  int
+	NotesFailed   int // rolled back with TxPerNote or ContinueOnError
+	TagsCreated   int
+	TagsReused    int
+	Links         int
+	TasksInserted int
+	NoteLinks     int // note_links rows written
+	Unresolved    int // wikilinks that matched no imported note
+	Elapsed       time.Duration
+}
+
+// Report actions
+const (
+	ActionInsert = "insert"
+	ActionSkip   = "skip"
+	ActionUpdate = "update"
+	ActionFail   = "fail" // rolled back with TxPerNote or ContinueOnError
+)
+
+// ReportEntry is one note in the import report.
+type ReportEntry struct {
+	Path      string    `json:"path"`
+	Title     string    `json:"title"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Action    string    `json:"action"`
+	Error     string    `json:"error,omitempty"` // why the note failed, for ActionFail
 }
@@ -0,0 +1,199 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// isArchive reports whether root names a supported archive.
+func isArchive(root string) bool {
+	lower := strings.ToLower(root)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// archiveEntry is a markdown member read into memory.
+type archiveEntry struct {
+	name    string // slash-separated path inside the archive
+	data    []byte
+	modTime time.Time
+}
+
+// discoverArchive parses the .md members of a zip or tar.gz archive. Notes get
+// paths of the form <archive>/<member>, so folder tags and RelPath come from
+// the member's path inside the archive. Timestamps come from entry metadata.
+// Only an archive that can't be opened is fatal; a member that fails to
+// decompress is passed to fileErr and skipped.
+func discoverArchive(cfg models.Config, keep keepFunc, fileErr func(string, error), stats *models.DiscoveryStats) ([]models.Note, error) {
+	var (
+		entries []archiveEntry
+		err     error
+	)
+	if strings.HasSuffix(strings.ToLower(cfg.Root), ".zip") {
+		entries, err = readZip(cfg, keep, fileErr, stats)
+	} else {
+		entries, err = readTarGz(cfg, keep, fileErr, stats)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read archive %s: %w", cfg.Root, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	if cfg.Preview > 0 && len(entries) > cfg.Preview {
+		entries = entries[:cfg.Preview]
+	}
+	stats.Files += len(entries)
+
+	notes := make([]models.Note, 0, len(entries))
+	for _, e := range entries {
+		p := filepath.Join(cfg.Root, filepath.FromSlash(e.name))
+		n, err := parseNote(cfg, p, e.data, e.modTime, e.modTime)
+		if err != nil {
+			fileErr(p, err)
+			continue
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+// wantArchiveMember applies the markdown, exclude and include checks to a member path.
+func wantArchiveMember(cfg models.Config, name string) bool {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || strings.HasPrefix(name, "../") || !isMarkdown(cfg.Extensions, name) {
+		return false
+	}
+	rel := filepath.FromSlash(name)
+	// An excluded folder excludes everything below it
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if matchesAny(cfg.Exclude, dir) {
+			return false
+		}
+	}
+	if matchesAny(cfg.Exclude, rel) {
+		return false
+	}
+	return len(cfg.Include) == 0 || matchesAny(cfg.Include, rel)
+}
+
+// archiveLimitReached reports whether entries has reached cfg.DryRunLimit,
+// marking stats as limited so the reader stops before decompressing more.
+func archiveLimitReached(cfg models.Config, entries []archiveEntry, stats *models.DiscoveryStats) bool {
+	if cfg.DryRunLimit > 0 && len(entries) >= cfg.DryRunLimit {
+		stats.Limited = true
+		return true
+	}
+	return false
+}
+
+func readZip(cfg models.Config, keep keepFunc, fileErr func(string, error), stats *models.DiscoveryStats) ([]archiveEntry, error) {
+	zr, err := zip.OpenReader(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !wantArchiveMember(cfg, f.Name) {
+			continue
+		}
+		p := filepath.Join(cfg.Root, filepath.FromSlash(f.Name))
+		if !keep(p, int64(f.UncompressedSize64), f.Modified) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			fileErr(p, err)
+			continue
+		}
+		data, err := readLimited(rc, cfg.MaxBodySize)
+		rc.Close()
+		if err != nil {
+			fileErr(p, err)
+			continue
+		}
+		entries = append(entries, archiveEntry{name: path.Clean(f.Name), data: data, modTime: f.Modified})
+		if archiveLimitReached(cfg, entries, stats) {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func readTarGz(cfg models.Config, keep keepFunc, fileErr func(string, error), stats *models.DiscoveryStats) ([]archiveEntry, error) {
+	f, err := os.Open(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var entries []archiveEntry
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A damaged stream can't be read past; keep what came before it
+			fileErr(cfg.Root, err)
+			break
+		}
+		if hdr.Typeflag != tar.TypeReg || !wantArchiveMember(cfg, hdr.Name) {
+			continue
+		}
+		p := filepath.Join(cfg.Root, filepath.FromSlash(hdr.Name))
+		if !keep(p, hdr.Size, hdr.ModTime) {
+			continue
+		}
+		data, err := readLimited(tr, cfg.MaxBodySize)
+		if err != nil {
+			// Nor can the stream go on past a member that failed to decompress
+			fileErr(p, err)
+			break
+		}
+		entries = append(entries, archiveEntry{name: path.Clean(strings.TrimPrefix(hdr.Name, "/")), data: data, modTime: hdr.ModTime})
+		if archiveLimitReached(cfg, entries, stats) {
+			break
+		}
+	}
+	return entries, nil
+}
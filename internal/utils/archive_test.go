@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var archiveMembers = []string{"a.md", "b.md", "c.md", "d.md", "e.md"}
+
+// writeTestZip writes an uncompressed zip of archiveMembers and returns its path.
+func writeTestZip(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range archiveMembers {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("# " + name + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "notes.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testTarGz returns a tar.gz of archiveMembers, padded with random text so
+// each member takes up a similar share of the compressed stream.
+func testTarGz(t *testing.T) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range archiveMembers {
+		pad := make([]byte, 2048)
+		rng.Read(pad)
+		body := []byte("# " + name + "\n\n" + hex.EncodeToString(pad) + "\n")
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func discoverArchiveNames(t *testing.T, root string, limit int) ([]string, bool) {
+	t.Helper()
+	cfg := testConfig()
+	cfg.Root = root
+	cfg.DryRunLimit = limit
+	notes, stats, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, n := range notes {
+		names = append(names, filepath.ToSlash(n.RelPath))
+	}
+	return names, stats.Limited
+}
+
+func TestArchiveDryRunLimit(t *testing.T) {
+	names, limited := discoverArchiveNames(t, writeTestZip(t), 2)
+	if want := []string{"a.md", "b.md"}; !reflect.DeepEqual(names, want) || !limited {
+		t.Errorf("zip: %q, limited %v; want %q, true", names, limited, want)
+	}
+
+	// The limit stops the read, so damage past it goes unnoticed
+	data := testTarGz(t)
+	path := filepath.Join(t.TempDir(), "notes.tar.gz")
+	if err := os.WriteFile(path, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, limited = discoverArchiveNames(t, path, 1)
+	if want := []string{"a.md"}; !reflect.DeepEqual(names, want) || !limited {
+		t.Errorf("tar.gz: %q, limited %v; want %q, true", names, limited, want)
+	}
+
+	names, limited = discoverArchiveNames(t, writeTestZip(t), 0)
+	if !reflect.DeepEqual(names, archiveMembers) || limited {
+		t.Errorf("no limit: %q, limited %v; want %q, false", names, limited, archiveMembers)
+	}
+}
+
+func TestArchiveMemberErrorsAreSkipped(t *testing.T) {
+	// Corrupting a stored member's bytes fails its checksum
+	path := writeTestZip(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(data, []byte("# c.md"))
+	data[i+2] = 'x'
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.Root = path
+	notes, stats, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 4 || len(stats.FileErrors) != 1 || !strings.Contains(stats.FileErrors[0], "c.md") {
+		t.Errorf("zip: %d notes, file errors %q; want 4 notes and c.md's error", len(notes), stats.FileErrors)
+	}
+
+	// A truncated tar.gz keeps the members before the damage
+	tgz := testTarGz(t)
+	path = filepath.Join(t.TempDir(), "notes.tar.gz")
+	if err := os.WriteFile(path, tgz[:len(tgz)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Root = path
+	notes, stats, err = DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) == 0 || len(notes) == len(archiveMembers) || len(stats.FileErrors) != 1 {
+		t.Errorf("tar.gz: %d notes, file errors %q; want some notes and one error", len(notes), stats.FileErrors)
+	}
+
+	cfg.FailOnError = true
+	if _, _, err := DiscoverNotes(cfg); err == nil {
+		t.Error("want an error with FailOnError")
+	}
+}
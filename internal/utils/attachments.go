@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"net/url"
+	pathpkg "path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// mdLinkRegex matches inline markdown links and images: [text](dest "title")
+// and ![alt](<dest with spaces>).
+var mdLinkRegex = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*(<[^>]*>|[^)\s]+)(\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+
+// rewriteAttachmentLinks points relative image and attachment links in body,
+// which resolve against the note at notePath (relative to Root), at the same
+// path under baseURL. External URLs, absolute paths, anchors, links to other
+// notes (files with one of exts) and paths that climb out of Root are left
+// as they are.
+func rewriteAttachmentLinks(body, notePath, baseURL string, exts []string) string {
+	base := strings.TrimSuffix(baseURL, "/")
+	dir := pathpkg.Dir(filepath.ToSlash(notePath))
+
+	return mdLinkRegex.ReplaceAllStringFunc(body, func(link string) string {
+		m := mdLinkRegex.FindStringSubmatch(link)
+		bang, text, dest, title := m[1], m[2], m[3], m[4]
+
+		target := strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+		suffix := ""
+		if i := strings.IndexAny(target, "?#"); i >= 0 {
+			target, suffix = target[:i], target[i:]
+		}
+		if target == "" || strings.HasPrefix(target, "/") {
+			return link
+		}
+		if u, err := url.Parse(target); err != nil || u.Scheme != "" || u.Host != "" {
+			return link
+		}
+		if bang == "" && isMarkdown(exts, target) {
+			return link
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+
+		resolved := pathpkg.Join(dir, target)
+		if resolved == ".." || strings.HasPrefix(resolved, "../") {
+			return link
+		}
+		segments := strings.Split(resolved, "/")
+		for i, s := range segments {
+			segments[i] = url.PathEscape(s)
+		}
+		return bang + "[" + text + "](" + base + "/" + strings.Join(segments, "/") + suffix + title + ")"
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.TemplatePosition
+const (
+	TemplatePrepend = "prepend" // rendered template, blank line, body
+	TemplateAppend  = "append"  // body, blank line, rendered template
+)
+
+// ValidateTemplatePosition rejects unsupported --template-position values.
+func ValidateTemplatePosition(position string) error {
+	switch position {
+	case TemplatePrepend, TemplateAppend:
+		return nil
+	}
+	return fmt.Errorf("unsupported template position %q (want %s or %s)", position, TemplatePrepend, TemplateAppend)
+}
+
+// ParseBodyTemplate parses a --body-template string.
+func ParseBodyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("body").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse --body-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// bodyTemplateData is what a --body-template sees: the note's fields, plus
+// Date, the day of the import as YYYY-MM-DD.
+type bodyTemplateData struct {
+	models.Note
+	Date string
+}
+
+// applyBodyTemplate renders cfg.BodyTmpl for n and adds it to the start or
+// end of body, per cfg.TemplatePosition.
+func applyBodyTemplate(cfg models.Config, n models.Note, body string) (string, error) {
+	var b strings.Builder
+	data := bodyTemplateData{Note: n, Date: time.Now().Format("2006-01-02")}
+	if err := cfg.BodyTmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("body template: %w", err)
+	}
+	rendered := strings.TrimRight(b.String(), "\n")
+	if rendered == "" {
+		return body, nil
+	}
+	if cfg.TemplatePosition == TemplateAppend {
+		return strings.TrimRight(body, "\n") + "\n\n" + rendered + "\n", nil
+	}
+	return rendered + "\n\n" + strings.TrimLeft(body, "\n"), nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// busyBackoff is the delay before the first retry; it doubles after each attempt.
+const busyBackoff = 100 * time.Millisecond
+
+// IsBusy reports whether err means another connection holds a lock on the
+// database, e.g. Tududi writing while we import.
+func IsBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		return se.Code == sqlite3.ErrBusy || se.Code == sqlite3.ErrLocked
+	}
+	return strings.Contains(err.Error(), "database is locked")
+}
+
+// RetryBusy runs fn, retrying it up to cfg.BusyRetries times with exponential
+// backoff while it fails with a busy error. Any other error is returned as is.
+func RetryBusy(cfg models.Config, fn func() error) error {
+	delay := busyBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if !IsBusy(err) || attempt >= cfg.BusyRetries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// insertID runs DialectFor(cfg).InsertID under RetryBusy, passing the
+// statement to cfg.SQLRecorder, with the new row's id made explicit, once it
+// succeeds.
+func insertID(tx *sql.Tx, cfg models.Config, query string, args ...interface{}) (id int64, err error) {
+	err = RetryBusy(cfg, func() error {
+		id, err = DialectFor(cfg).InsertID(tx, query, args...)
+		return err
+	})
+	if err == nil && cfg.SQLRecorder != nil {
+		query, args := withInsertedID(query, args, id)
+		cfg.SQLRecorder.Record(query, args...)
+	}
+	return id, err
+}
+
+// execWrite runs a write statement (with ? placeholders) under RetryBusy,
+// passing it to cfg.SQLRecorder once it succeeds.
+func execWrite(tx *sql.Tx, cfg models.Config, query string, args ...interface{}) error {
+	_, err := execCount(tx, cfg, query, args...)
+	return err
+}
+
+// execCount is execWrite that also returns the number of rows affected.
+func execCount(tx *sql.Tx, cfg models.Config, query string, args ...interface{}) (n int64, err error) {
+	err = RetryBusy(cfg, func() error {
+		res, err := tx.Exec(DialectFor(cfg).Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err == nil && cfg.SQLRecorder != nil {
+		cfg.SQLRecorder.Record(query, args...)
+	}
+	return n, err
+}
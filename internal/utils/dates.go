@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// flexibleDateLayouts are tried in order by parseFlexibleDate. Layouts
+// without a zone are read as UTC.
+var flexibleDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// parseFlexibleDate parses s using the first of flexibleDateLayouts that
+// matches, or as Unix seconds (milliseconds when 13+ digits) if it is an integer.
+func parseFlexibleDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if len(strings.TrimPrefix(s, "-")) >= 13 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range flexibleDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q (tried Unix seconds/milliseconds and %s)",
+		s, strings.Join(flexibleDateLayouts, ", "))
+}
+
+// flexTime is a frontmatter date decoded with parseFlexibleDate.
+type flexTime struct {
+	time.Time
+}
+
+func (t *flexTime) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: date must be a single value", node.Line)
+	}
+	if node.Value == "" {
+		return nil
+	}
+	parsed, err := parseFlexibleDate(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// flexibleDateFromFilename finds the longest leading run of words in name,
+// ending before a space, underscore or the extension, that parseFlexibleDate
+// accepts: "2024-03-15 Meeting.md" and "Jan 2, 2024 Notes.md" both work.
+// Bare numbers are not taken as Unix timestamps here ("1234 Ideas.md").
+func flexibleDateFromFilename(name string) (time.Time, bool) {
+	for end := len(name); end > 0; end-- {
+		if end < len(name) && !unicode.IsSpace(rune(name[end])) && name[end] != '_' && name[end] != '.' {
+			continue
+		}
+		prefix := name[:end]
+		if _, err := strconv.ParseInt(strings.TrimSpace(prefix), 10, 64); err == nil {
+			continue
+		}
+		if t, err := parseFlexibleDate(prefix); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
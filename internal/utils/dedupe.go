@@ -0,0 +1,162 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.DedupeNotes
+const (
+	DedupeMerge  = "merge"  // combine notes sharing a title into one
+	DedupeFolder = "folder" // keep them apart, appending the folder name to the title
+	DedupeNumber = "number" // keep them apart as "Title", "Title (2)", "Title (3)", ...
+)
+
+// mergeSeparator goes between bodies of merged notes.
+const mergeSeparator = "\n\n---\n\n"
+
+// ValidateDedupeMode rejects unsupported --dedupe-notes values.
+func ValidateDedupeMode(mode string) error {
+	switch mode {
+	case "", DedupeMerge, DedupeFolder, DedupeNumber:
+		return nil
+	}
+	return fmt.Errorf("unsupported dedupe mode %q (want %s, %s or %s)", mode, DedupeMerge, DedupeFolder, DedupeNumber)
+}
+
+// dedupeNotes resolves notes with identical titles according to cfg.DedupeNotes.
+// notes must already be sorted by path; the first note of a group keeps its
+// place (and, when numbering, its title), so results are stable across runs.
+// It returns the resulting notes and how many were merged or renamed.
+func dedupeNotes(cfg models.Config, notes []models.Note) ([]models.Note, int) {
+	if cfg.DedupeNotes == "" {
+		return notes, 0
+	}
+
+	groups := make(map[string][]int)
+	taken := make(map[string]bool)
+	for i, n := range notes {
+		groups[n.Title] = append(groups[n.Title], i)
+		taken[n.Title] = true
+	}
+
+	affected := 0
+	dropped := make(map[int]bool)
+	for _, idx := range groups {
+		if len(idx) < 2 {
+			continue
+		}
+		switch cfg.DedupeNotes {
+		case DedupeMerge:
+			first := &notes[idx[0]]
+			for _, i := range idx[1:] {
+				mergeNote(first, notes[i])
+				dropped[i] = true
+				affected++
+			}
+		case DedupeFolder:
+			for _, i := range idx {
+				dir := filepath.Dir(notes[i].RelPath)
+				if dir == "." {
+					continue
+				}
+				notes[i].Title = fmt.Sprintf("%s (%s)", notes[i].Title, filepath.Base(dir))
+				affected++
+			}
+		case DedupeNumber:
+			// Counters skip numbers another note's title already uses
+			num := 1
+			for _, i := range idx[1:] {
+				title := notes[i].Title
+				for {
+					num++
+					candidate := fmt.Sprintf("%s (%d)", title, num)
+					if !taken[candidate] {
+						notes[i].Title = candidate
+						taken[candidate] = true
+						break
+					}
+				}
+				affected++
+			}
+		}
+	}
+
+	if len(dropped) == 0 {
+		return notes, affected
+	}
+	out := make([]models.Note, 0, len(notes)-len(dropped))
+	for i, n := range notes {
+		if !dropped[i] {
+			out = append(out, n)
+		}
+	}
+	return out, affected
+}
+
+// mergeNote appends other's body, tags, tasks and links onto n, adds
+// other's metadata fields that n lacks and widens its dates.
+func mergeNote(n *models.Note, other models.Note) {
+	n.Body = strings.TrimRight(n.Body, "\n") + mergeSeparator + other.Body
+	n.Tags = append(n.Tags, other.Tags...)
+	n.Tasks = append(n.Tasks, other.Tasks...)
+	n.Links = UniqueStrings(append(n.Links, other.Links...))
+	n.Metadata = mergeMetadata(n.Metadata, other.Metadata)
+	n.ContentHash = ContentHash(n.ContentHash + other.ContentHash)
+	if other.CreatedAt.Before(n.CreatedAt) {
+		n.CreatedAt = other.CreatedAt
+	}
+	if other.UpdatedAt.After(n.UpdatedAt) {
+		n.UpdatedAt = other.UpdatedAt
+	}
+}
+
+// mergeMetadata combines two JSON metadata objects, first winning on keys
+// both have. Values are copied verbatim.
+func mergeMetadata(first, second string) string {
+	if second == "" {
+		return first
+	}
+	if first == "" {
+		return second
+	}
+	var a, b map[string]json.RawMessage
+	if json.Unmarshal([]byte(first), &a) != nil || json.Unmarshal([]byte(second), &b) != nil {
+		return first
+	}
+	for key, value := range b {
+		if _, ok := a[key]; !ok {
+			a[key] = value
+		}
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return first
+	}
+	return string(data)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	tests := []struct{ first, second, want string }{
+		{"", "", ""},
+		{`{"a":1}`, "", `{"a":1}`},
+		{"", `{"b":2}`, `{"b":2}`},
+		{`{"a":1}`, `{"b":[2,3]}`, `{"a":1,"b":[2,3]}`},
+		{`{"a":1,"s":"first"}`, `{"s":"second"}`, `{"a":1,"s":"first"}`},
+		{`{"id":12345678901234567890}`, `{"x":true}`, `{"id":12345678901234567890,"x":true}`},
+	}
+	for _, tt := range tests {
+		if got := mergeMetadata(tt.first, tt.second); got != tt.want {
+			t.Errorf("mergeMetadata(%q, %q) = %q; want %q", tt.first, tt.second, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeMergeKeepsLinksAndMetadata(t *testing.T) {
+	cfg := testConfig()
+	cfg.DedupeNotes = DedupeMerge
+	notes := []models.Note{
+		{Title: "Same", Body: "one\n", RelPath: "a.md", Links: []string{"Alpha", "Shared"}, Metadata: `{"status":"open"}`},
+		{Title: "Same", Body: "two\n", RelPath: "b.md", Links: []string{"Shared", "Beta"}, Metadata: `{"status":"done","owner":"sam"}`},
+	}
+	out, merged := dedupeNotes(cfg, notes)
+	if len(out) != 1 || merged != 1 {
+		t.Fatalf("%d notes, %d merged; want 1, 1", len(out), merged)
+	}
+	if want := []string{"Alpha", "Shared", "Beta"}; !reflect.DeepEqual(out[0].Links, want) {
+		t.Errorf("links %q; want %q", out[0].Links, want)
+	}
+	if want := `{"owner":"sam","status":"open"}`; out[0].Metadata != want {
+		t.Errorf("metadata %s; want %s", out[0].Metadata, want)
+	}
+}
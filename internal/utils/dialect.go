@@ -0,0 +1,194 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.Driver
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// Dialect hides the SQL differences between the supported backends. Queries
+// are written with ? placeholders and SQLite-style plain INSERTs.
+type Dialect interface {
+	// Rebind converts ? placeholders to the backend's placeholder style.
+	Rebind(query string) string
+	// InsertID runs an INSERT written with ? placeholders and returns the id of the new row.
+	InsertID(tx *sql.Tx, query string, args ...interface{}) (int64, error)
+	// InsertOrIgnore turns "INSERT INTO ..." into an insert that skips duplicates.
+	InsertOrIgnore(query string) string
+	// TableColumns returns the column names of table; a missing table yields an empty set.
+	TableColumns(db *sql.DB, table string) (map[string]bool, error)
+}
+
+// DialectFor returns the Dialect for cfg.Driver, defaulting to SQLite.
+func DialectFor(cfg models.Config) Dialect {
+	if cfg.Driver == DriverPostgres {
+		return postgresDialect{}
+	}
+	return sqliteDialect{}
+}
+
+// OpenDB opens cfg.DBPath with cfg.Driver. SQLite connections get
+// cfg.BusyTimeout as their busy_timeout and take the write lock when a
+// transaction begins: a failed COMMIT rolls the transaction back, so lock
+// contention has to surface at Begin, where it can be retried. The SQLite
+// pool is a single connection so that pragmas set by ApplyPragmas hold for
+// every statement.
+func OpenDB(cfg models.Config) (*sql.DB, error) {
+	if cfg.Driver == DriverPostgres {
+		return sql.Open(cfg.Driver, cfg.DBPath)
+	}
+	dsn := withDSNParam(cfg.DBPath, "_busy_timeout", strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10))
+	dsn = withDSNParam(dsn, "_txlock", "immediate")
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// withDSNParam appends key=value to a go-sqlite3 DSN unless it already sets key.
+func withDSNParam(dsn, key, value string) string {
+	i := strings.IndexByte(dsn, '?')
+	if i < 0 {
+		return dsn + "?" + key + "=" + value
+	}
+	for _, kv := range strings.Split(dsn[i+1:], "&") {
+		if strings.HasPrefix(kv, key+"=") {
+			return dsn
+		}
+	}
+	return dsn + "&" + key + "=" + value
+}
+
+// ValidateDriver rejects unsupported --driver values.
+func ValidateDriver(driver string) error {
+	switch driver {
+	case DriverSQLite, DriverPostgres:
+		return nil
+	}
+	return fmt.Errorf("unsupported driver %q (want %s or %s)", driver, DriverSQLite, DriverPostgres)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+func (sqliteDialect) InsertID(tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (sqliteDialect) InsertOrIgnore(query string) string {
+	query = strings.TrimSpace(query)
+	return "INSERT OR IGNORE" + strings.TrimPrefix(query, "INSERT")
+}
+
+func (sqliteDialect) TableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	query := fmt.Sprintf("PRAGMA table_info(%s)", quoteIdent(table))
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+type postgresDialect struct{}
+
+// Rebind numbers placeholders as $1, $2, ... Our queries never contain a
+// literal "?", so no quoting awareness is needed.
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// InsertID uses RETURNING since lib/pq doesn't support LastInsertId.
+func (d postgresDialect) InsertID(tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := tx.QueryRow(d.Rebind(strings.TrimSpace(query)+" RETURNING id"), args...).Scan(&id)
+	return id, err
+}
+
+func (postgresDialect) InsertOrIgnore(query string) string {
+	return strings.TrimSpace(query) + " ON CONFLICT DO NOTHING"
+}
+
+func (postgresDialect) TableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Diff classifications of a discovered note against the database
+const (
+	DiffNew       = "NEW"       // no note with this title
+	DiffDuplicate = "DUPLICATE" // same title and content
+	DiffUpdated   = "UPDATED"   // same title, different content
+)
+
+// LoadExistingNotes reads the user's notes keyed by title. When several notes
+// share a title the first one (by id) is kept. It only reads from the database.
+func LoadExistingNotes(db *sql.DB, cfg models.Config) (map[string]models.Note, error) {
+	selectSQL := `
+		SELECT title, ` + quoteIdent(notesContentCol(cfg)) + ` FROM notes
+		WHERE user_id = ?
+		ORDER BY id
+	`
+	rows, err := db.Query(DialectFor(cfg).Rebind(selectSQL), cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]models.Note)
+	for rows.Next() {
+		var title, content sql.NullString
+		if err := rows.Scan(&title, &content); err != nil {
+			return nil, err
+		}
+		if _, ok := existing[title.String]; ok {
+			continue
+		}
+		existing[title.String] = models.Note{Title: title.String, Body: content.String}
+	}
+	return existing, rows.Err()
+}
+
+// ClassifyNote compares a discovered note with the existing notes.
+func ClassifyNote(n models.Note, existing map[string]models.Note) string {
+	old, ok := existing[n.Title]
+	switch {
+	case !ok:
+		return DiffNew
+	case old.Body == n.Body:
+		return DiffDuplicate
+	default:
+		return DiffUpdated
+	}
+}
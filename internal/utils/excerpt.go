@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExcerptLength is how many characters of a note's body go into its excerpt.
+const ExcerptLength = 200
+
+var (
+	excerptImageRegex    = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	excerptLinkRegex     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	excerptWikilinkRegex = regexp.MustCompile(`!?\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+	excerptHTMLRegex     = regexp.MustCompile(`<[^>]+>`)
+	excerptPrefixRegex   = regexp.MustCompile(`^\s*(?:#{1,6}\s+|>\s*|[-*+]\s+(?:\[[ xX]\]\s+)?|\d+[.)]\s+)`)
+	excerptMarkRegex     = regexp.MustCompile("[*_~`]+")
+)
+
+// makeExcerpt returns the first n characters of body as plain text: headings,
+// list and quote markers, link and image syntax, emphasis and HTML tags are
+// stripped, code fences dropped and whitespace collapsed. Text cut short ends
+// at a word boundary with an ellipsis.
+func makeExcerpt(body string, n int) string {
+	var words []string
+	for _, line := range strings.Split(body, "\n") {
+		if fenceMarker(strings.TrimSpace(line)) != "" {
+			continue
+		}
+		line = excerptPrefixRegex.ReplaceAllString(line, "")
+		line = excerptImageRegex.ReplaceAllString(line, "")
+		line = excerptLinkRegex.ReplaceAllString(line, "$1")
+		line = excerptWikilinkRegex.ReplaceAllString(line, "$1")
+		line = excerptHTMLRegex.ReplaceAllString(line, "")
+		line = excerptMarkRegex.ReplaceAllString(line, "")
+		words = append(words, strings.Fields(line)...)
+	}
+	return truncateWords(strings.Join(words, " "), n)
+}
+
+// truncateWords returns text unchanged if it has at most n characters, and
+// otherwise its first n characters cut back to a word boundary with an
+// ellipsis appended.
+func truncateWords(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:n])
+	// Back off to a word boundary, unless the cut already falls on one
+	if runes[n] != ' ' {
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// noteExcerpt is the excerpt stored for n, leaving out a leading heading that
+// repeats the title.
+func noteExcerpt(title, body string) string {
+	trimmed := strings.TrimLeft(body, "\n")
+	first, rest, _ := strings.Cut(trimmed, "\n")
+	if headingRegex.MatchString(first) && strings.TrimSpace(strings.TrimLeft(first, "#")) == title {
+		body = rest
+	}
+	return makeExcerpt(body, ExcerptLength)
+}
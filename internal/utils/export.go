@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// LoadExportNotes reads the user's notes with their tags (sorted by name)
+// and timestamps, ordered by id. RelPath is where ExportPath puts each note.
+func LoadExportNotes(db *sql.DB, cfg models.Config) ([]models.Note, error) {
+	d := DialectFor(cfg)
+	tagsSQL := fmt.Sprintf(`
+		SELECT nt.note_id, t.%s FROM notes_tags nt
+		JOIN %s t ON t.id = nt.tag_id
+		WHERE t.user_id = ?
+		ORDER BY t.%s
+	`, quoteIdent(tagsNameCol(cfg)), quoteIdent(tagsTable(cfg)), quoteIdent(tagsNameCol(cfg)))
+	tagRows, err := db.Query(d.Rebind(tagsSQL), cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	noteTags := make(map[int64][]string)
+	for tagRows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := tagRows.Scan(&id, &name); err != nil {
+			tagRows.Close()
+			return nil, err
+		}
+		noteTags[id] = append(noteTags[id], name)
+	}
+	tagRows.Close()
+	if err := tagRows.Err(); err != nil {
+		return nil, err
+	}
+
+	notesSQL := `
+		SELECT id, title, ` + quoteIdent(notesContentCol(cfg)) + `, created_at, updated_at FROM notes
+		WHERE user_id = ?
+		ORDER BY id
+	`
+	rows, err := db.Query(d.Rebind(notesSQL), cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.Note
+	used := make(map[string]bool)
+	for rows.Next() {
+		var (
+			id               int64
+			title, content   sql.NullString
+			created, updated interface{}
+		)
+		if err := rows.Scan(&id, &title, &content, &created, &updated); err != nil {
+			return nil, err
+		}
+		n := models.Note{Title: title.String, Body: content.String, Tags: noteTags[id]}
+		if n.CreatedAt, err = dbTime(created); err != nil {
+			return nil, fmt.Errorf("note %d created_at: %w", id, err)
+		}
+		if n.UpdatedAt, err = dbTime(updated); err != nil {
+			return nil, fmt.Errorf("note %d updated_at: %w", id, err)
+		}
+		n.RelPath = ExportPath(n, used)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// ExportPath returns a slash-separated path for n: a folder per part of its
+// first tag, then its title as the file name. Characters that aren't safe
+// in file names become "-", and a path already in used gets " (2)", " (3)"
+// and so on. The path is added to used.
+func ExportPath(n models.Note, used map[string]bool) string {
+	var dir []string
+	if len(n.Tags) > 0 {
+		for _, part := range strings.Split(n.Tags[0], "/") {
+			if part = safeFileName(part); part != "" {
+				dir = append(dir, part)
+			}
+		}
+	}
+	name := safeFileName(n.Title)
+	if name == "" {
+		name = "untitled"
+	}
+	base := strings.Join(append(dir, name), "/")
+	rel := base + ".md"
+	for i := 2; used[strings.ToLower(rel)]; i++ {
+		rel = fmt.Sprintf("%s (%d).md", base, i)
+	}
+	used[strings.ToLower(rel)] = true
+	return rel
+}
+
+// safeFileName replaces path separators, reserved and control characters in
+// s with "-" and trims dots and spaces from the ends.
+func safeFileName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, s)
+	return strings.Trim(s, " .")
+}
+
+// dbTime converts a scanned created_at/updated_at value: a time.Time from
+// the driver, or text as the importer writes it ("2006-01-02 15:04:05.000
+// +00:00") or in any format parseFlexibleDate accepts. NULL is the zero time.
+func dbTime(v interface{}) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return time.Time{}, fmt.Errorf("unexpected type %T", v)
+	}
+	if t, err := time.Parse("2006-01-02 15:04:05.999999999 -07:00", s); err == nil {
+		return t, nil
+	}
+	return parseFlexibleDate(s)
+}
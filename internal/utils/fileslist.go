@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// LoadFileList reads newline-delimited file paths from path, or from stdin
+// when path is "-", as printed by find, fd or git diff --name-only. Blank
+// lines are skipped and relative paths are taken from the working directory.
+func LoadFileList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		abs, err := filepath.Abs(line)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, abs)
+	}
+	return files, scanner.Err()
+}
+
+// assignFiles splits files between roots, giving each file to the first root
+// it sits under. Every root must be a directory. Files under no root are
+// returned separately.
+func assignFiles(roots, files []string) (byRoot map[string][]string, outside []string, err error) {
+	absRoots := make([]string, len(roots))
+	for i, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.IsDir() {
+			return nil, nil, fmt.Errorf("--files-from needs directory roots, and %s is not a directory", root)
+		}
+		if absRoots[i], err = filepath.Abs(root); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byRoot = make(map[string][]string, len(roots))
+	for _, f := range files {
+		placed := false
+		for i, root := range absRoots {
+			if strings.HasPrefix(f, root+string(filepath.Separator)) {
+				byRoot[roots[i]] = append(byRoot[roots[i]], f)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			outside = append(outside, f)
+		}
+	}
+	return byRoot, outside, nil
+}
+
+// listedCandidates turns cfg.Files into parse candidates, applying the same
+// markdown, exclude/include, .gitignore and keep checks as the walk. Paths keep cfg.Root
+// as their base, so folder tags work as if the tree had been walked.
+func listedCandidates(cfg models.Config, keep keepFunc, fileErr func(string, error)) []candidate {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		root = cfg.Root
+	}
+	var (
+		candidates []candidate
+		ignore     gitignore
+		loaded     = make(map[string]bool) // folders whose .gitignore is in ignore
+	)
+	for _, abs := range cfg.Files {
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			fileErr(abs, err)
+			continue
+		}
+		path := filepath.Join(cfg.Root, rel)
+		info, err := os.Stat(path)
+		if err != nil {
+			fileErr(path, err)
+			continue
+		}
+		if !info.Mode().IsRegular() || !isMarkdown(cfg.Extensions, info.Name()) {
+			continue
+		}
+		excluded := matchesAny(cfg.Exclude, rel)
+		for dir := filepath.Dir(rel); dir != "." && !excluded; dir = filepath.Dir(dir) {
+			excluded = matchesAny(cfg.Exclude, dir)
+		}
+		if excluded || (len(cfg.Include) > 0 && !matchesAny(cfg.Include, rel)) {
+			continue
+		}
+		if cfg.RespectGitignore {
+			ignored, err := ignore.ignoredFile(cfg.Root, rel, loaded)
+			if err != nil {
+				fileErr(path, fmt.Errorf("read .gitignore: %w", err))
+				continue
+			}
+			if ignored {
+				continue
+			}
+		}
+		if !keep(path, info.Size(), info.ModTime()) {
+			continue
+		}
+		candidates = append(candidates, candidate{path: path, info: info})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].path < candidates[j].path })
+	return candidates
+}
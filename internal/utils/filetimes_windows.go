@@ -0,0 +1,44 @@
+//go:build windows
+
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileTimes returns the file's creation time and modification time.
+// Created falls back to ModTime when no creation time is available.
+func fileTimes(info os.FileInfo, path string) (created, modified time.Time) {
+	modified = info.ModTime()
+	created = modified
+
+	if attr, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		if ns := attr.CreationTime.Nanoseconds(); ns > 0 {
+			created = time.Unix(0, ns)
+		}
+	}
+	return created, modified
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for Config.Frontmatter
+const (
+	FrontmatterOff   = "off"   // leave the block in the body
+	FrontmatterStrip = "strip" // remove the block without interpreting it
+	FrontmatterParse = "parse" // remove the block and use its fields
+)
+
+// ValidateFrontmatterMode rejects unsupported --frontmatter values.
+func ValidateFrontmatterMode(mode string) error {
+	switch mode {
+	case FrontmatterOff, FrontmatterStrip, FrontmatterParse:
+		return nil
+	}
+	return fmt.Errorf("unsupported frontmatter mode %q (want %s, %s or %s)", mode, FrontmatterOff, FrontmatterStrip, FrontmatterParse)
+}
+
+// frontmatter holds the fields we understand from a leading YAML block.
+type frontmatter struct {
+	Title   string   `yaml:"title"`
+	Tags    tagValue `yaml:"tags"`
+	Aliases yamlList `yaml:"aliases"`
+	Created flexTime `yaml:"created"`
+	Updated flexTime `yaml:"updated"`
+	Draft   flexBool `yaml:"draft"`
+	Publish flexBool `yaml:"publish"`
+}
+
+// flexBool is a frontmatter flag. It accepts true/false, yes/no and 1/0 in
+// any case; other values leave it unset rather than failing the note.
+type flexBool struct {
+	Set   bool
+	Value bool
+}
+
+func (b *flexBool) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(node.Value)) {
+	case "true", "yes", "1":
+		*b = flexBool{Set: true, Value: true}
+	case "false", "no", "0":
+		*b = flexBool{Set: true, Value: false}
+	}
+	return nil
+}
+
+// isDraft reports whether fm marks its note as not for import: draft: true
+// or publish: false.
+func (fm frontmatter) isDraft() bool {
+	return (fm.Draft.Set && fm.Draft.Value) || (fm.Publish.Set && !fm.Publish.Value)
+}
+
+// yamlList accepts either a YAML sequence or a single scalar, as Obsidian
+// writes "aliases: Foo" and "aliases: [Foo, Bar]" interchangeably.
+type yamlList []string
+
+func (l *yamlList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "" {
+			*l = yamlList{node.Value}
+		}
+		return nil
+	}
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// tagValue is a frontmatter tags: field. Exporters write either a YAML
+// sequence or one string such as "work, personal; urgent"; the string is kept
+// whole in Scalar for parseNote to split on cfg.TagSeparators.
+type tagValue struct {
+	List   []string
+	Scalar string
+}
+
+func (v *tagValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Scalar = node.Value
+		return nil
+	}
+	return node.Decode(&v.List)
+}
+
+// splitTagString splits s on any of the characters in seps, dropping empty
+// parts. An empty seps leaves s whole.
+func splitTagString(s, seps string) []string {
+	parts := []string{s}
+	if seps != "" {
+		parts = strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(seps, r) })
+	}
+	var tags []string
+	for _, p := range parts {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			tags = append(tags, p)
+		}
+	}
+	return tags
+}
+
+// splitFrontmatter separates a leading "---" delimited YAML block from the rest
+// of the text. ok is false when the text does not start with a frontmatter block.
+func splitFrontmatter(text string) (block, body string, ok bool) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, false
+	}
+	rest := text[len("---\n"):]
+
+	offset := 0
+	for offset <= len(rest) {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if strings.TrimSpace(line) == "---" {
+			block = rest[:offset]
+			if end < 0 {
+				return block, "", true
+			}
+			return block, rest[offset+end+1:], true
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	// No closing delimiter, so this isn't frontmatter.
+	return "", text, false
+}
+
+// knownFrontmatterKeys are the fields frontmatter already feeds into a note,
+// plus the draft flags SkipDrafts reads.
+var knownFrontmatterKeys = map[string]bool{
+	"title": true, "tags": true, "aliases": true, "created": true, "updated": true,
+	"draft": true, "publish": true,
+}
+
+// frontmatterMetadata returns the fields of block that aren't one of
+// knownFrontmatterKeys as a JSON object, or "" when there are none.
+func frontmatterMetadata(block string) (string, error) {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal([]byte(block), &fields); err != nil {
+		return "", err
+	}
+	for key := range fields {
+		if knownFrontmatterKeys[strings.ToLower(key)] {
+			delete(fields, key)
+		}
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseFrontmatter decodes a YAML frontmatter block.
+func parseFrontmatter(block string) (frontmatter, error) {
+	var fm frontmatter
+	if err := yaml.Unmarshal([]byte(block), &fm); err != nil {
+		return frontmatter{}, err
+	}
+	return fm, nil
+}
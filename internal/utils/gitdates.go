@@ -0,0 +1,85 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// gitRecordSep starts each commit in the git log output parsed by LoadGitDates.
+const gitRecordSep = "\x1e"
+
+// InGitWorkTree reports whether dir is inside a git work tree. It is false
+// when git itself isn't installed.
+func InGitWorkTree(dir string) bool {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// LoadGitDates runs one git log over dir and returns, for every file a commit
+// touched, the author dates of its first and last commits. Keys are
+// slash-separated paths relative to dir.
+func LoadGitDates(dir string) (map[string]models.FileDates, error) {
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "-C", dir,
+		"log", "--relative", "--no-renames", "--name-only", "--format="+gitRecordSep+"%aI")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git log in %s: %s", dir, msg)
+		}
+		return nil, fmt.Errorf("git log in %s: %w", dir, err)
+	}
+
+	// Commits come newest first, so the first date seen for a file is its
+	// last change and the final one its first
+	dates := make(map[string]models.FileDates)
+	for _, record := range strings.Split(string(out), gitRecordSep) {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if len(lines) < 2 {
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, strings.TrimSpace(lines[0]))
+		if err != nil {
+			return nil, fmt.Errorf("git log in %s: bad date %q", dir, lines[0])
+		}
+		for _, name := range lines[1:] {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			d, seen := dates[name]
+			if !seen {
+				d.Updated = when
+			}
+			d.Created = when
+			dates[name] = d
+		}
+	}
+	return dates, nil
+}
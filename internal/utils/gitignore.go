@@ -0,0 +1,169 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern line from a .gitignore file.
+type ignoreRule struct {
+	base     string // slash-separated dir of the .gitignore, relative to Root ("" for Root)
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern contains a slash, so it's relative to base rather than any depth
+}
+
+// gitignore accumulates rules from the .gitignore files seen during a walk.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load reads the .gitignore in relDir (relative to root), if there is one.
+func (g *gitignore) load(root, relDir string) error {
+	f, err := os.Open(filepath.Join(root, relDir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	base := filepath.ToSlash(relDir)
+	if base == "." {
+		base = ""
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		g.rules = append(g.rules, rule)
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether rel (relative to Root) is ignored. As in git, the
+// last matching rule wins, so later "!pattern" lines can re-include paths.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, r.base+"/")
+		}
+
+		var ok bool
+		if r.anchored {
+			ok = matchGlobPath(r.pattern, sub)
+		} else {
+			ok, _ = path.Match(r.pattern, path.Base(sub))
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// ignoredFile reports whether rel, a file relative to root, would be left out
+// of a walk honouring .gitignore: it or a folder above it is ignored, or it
+// sits in .git. The .gitignore of each folder on the way is loaded into g the
+// first time it is reached; loaded records which have been.
+func (g *gitignore) ignoredFile(root, rel string, loaded map[string]bool) (bool, error) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	dir := "."
+	for i, part := range parts {
+		if !loaded[dir] {
+			loaded[dir] = true
+			if err := g.load(root, filepath.FromSlash(dir)); err != nil {
+				return false, err
+			}
+		}
+		sub := strings.Join(parts[:i+1], "/")
+		if part == ".git" || g.ignored(sub, i < len(parts)-1) {
+			return true, nil
+		}
+		dir = sub
+	}
+	return false, nil
+}
+
+// matchGlobPath matches a slash-separated path against a pattern where "**"
+// matches any number of path segments.
+func matchGlobPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
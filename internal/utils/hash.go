@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// ContentHash returns the hex SHA-256 of a note body.
+func ContentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindNoteBySource looks up the user's note imported from relPath, returning
+// its id and stored content_hash. found is false when there is none.
+func FindNoteBySource(tx *sql.Tx, cfg models.Config, relPath string) (id int64, hash string, found bool, err error) {
+	selectSQL := `
+		SELECT id, content_hash FROM notes
+		WHERE user_id = ? AND source_path = ?
+		LIMIT 1
+	`
+	var stored sql.NullString
+	err = RetryBusy(cfg, func() error {
+		return tx.QueryRow(DialectFor(cfg).Rebind(selectSQL), cfg.UserID, relPath).Scan(&id, &stored)
+	})
+	if err == sql.ErrNoRows {
+		return 0, "", false, nil
+	}
+	if err != nil {
+		return 0, "", false, err
+	}
+	return id, stored.String, true, nil
+}
+
+// UpdateNote overwrites the title, content, content_hash and, when enabled,
+// excerpt and metadata of note id and bumps its updated_at.
+func UpdateNote(tx *sql.Tx, cfg models.Config, id int64, n models.Note) error {
+	updatedStr := n.UpdatedAt.UTC().Format("2006-01-02 15:04:05.000 +00:00")
+	if n.UpdatedAt.IsZero() {
+		updatedStr = time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
+	}
+	set := []string{"title = ?", quoteIdent(notesContentCol(cfg)) + " = ?", "content_hash = ?"}
+	args := []interface{}{n.Title, n.Body, n.ContentHash}
+	if cfg.Excerpt {
+		set = append(set, "excerpt = ?")
+		args = append(args, noteExcerpt(n.Title, n.Body))
+	}
+	if cfg.FrontmatterMeta {
+		set = append(set, "metadata = ?")
+		args = append(args, nullIfEmpty(n.Metadata))
+	}
+	set = append(set, "updated_at = ?")
+	args = append(args, updatedStr, id)
+
+	updateSQL := "UPDATE notes SET " + strings.Join(set, ", ") + " WHERE id = ?"
+	return execWrite(tx, cfg, updateSQL, args...)
+}
+
+// UnlinkNoteTags removes every notes_tags row of note id, so an updated note
+// can be linked to its current tags.
+func UnlinkNoteTags(tx *sql.Tx, cfg models.Config, id int64) error {
+	return execWrite(tx, cfg, "DELETE FROM notes_tags WHERE note_id = ?", id)
+}
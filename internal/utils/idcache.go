@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import "sync"
+
+// IDCache maps names (keyed as name|userID) to row ids. It is safe for
+// concurrent use; GetOrCreate holds the lock while resolving a missing key,
+// so two callers never create the same row.
+type IDCache struct {
+	mu  sync.Mutex
+	ids map[string]int64
+}
+
+// NewIDCache returns an empty cache.
+func NewIDCache() *IDCache {
+	return &IDCache{ids: make(map[string]int64)}
+}
+
+// Get returns the id cached for key.
+func (c *IDCache) Get(key string) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, ok := c.ids[key]
+	return id, ok
+}
+
+// Set caches id for key.
+func (c *IDCache) Set(key string, id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids[key] = id
+}
+
+// GetOrCreate returns the id cached for key, or calls resolve and caches what
+// it returns. created is resolve's report of whether it inserted a new row,
+// and is false for a cache hit.
+func (c *IDCache) GetOrCreate(key string, resolve func() (id int64, created bool, err error)) (int64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id, ok := c.ids[key]; ok {
+		return id, false, nil
+	}
+	id, created, err := resolve()
+	if err != nil {
+		return 0, false, err
+	}
+	c.ids[key] = id
+	return id, created, nil
+}
+
+// Clone returns an independent copy of the cache.
+func (c *IDCache) Clone() *IDCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := &IDCache{ids: make(map[string]int64, len(c.ids))}
+	for k, v := range c.ids {
+		out.ids[k] = v
+	}
+	return out
+}
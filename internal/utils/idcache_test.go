@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Run with -race: workers share one cache, so a check-then-insert gap would
+// resolve (and insert) the same key more than once.
+func TestIDCacheGetOrCreateConcurrent(t *testing.T) {
+	const workers, keys = 16, 8
+	cache := NewIDCache()
+	var resolves [keys]int32
+	var created int32
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				k := k
+				id, isNew, err := cache.GetOrCreate(fmt.Sprintf("key%d", k), func() (int64, bool, error) {
+					atomic.AddInt32(&resolves[k], 1)
+					return int64(100 + k), true, nil
+				})
+				if err != nil || id != int64(100+k) {
+					t.Errorf("key%d: got %d, %v; want %d", k, id, err, 100+k)
+				}
+				if isNew {
+					atomic.AddInt32(&created, 1)
+				}
+				cache.Clone()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k, n := range resolves {
+		if n != 1 {
+			t.Errorf("key%d resolved %d times; want 1", k, n)
+		}
+	}
+	if created != keys {
+		t.Errorf("%d creations reported; want %d", created, keys)
+	}
+}
+
+func TestIDCacheGetOrCreateError(t *testing.T) {
+	cache := NewIDCache()
+	if _, _, err := cache.GetOrCreate("k", func() (int64, bool, error) { return 0, false, fmt.Errorf("boom") }); err == nil {
+		t.Fatal("want resolve error")
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Error("failed resolve was cached")
+	}
+}
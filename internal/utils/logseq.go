@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Supported values for Config.Format
+const (
+	FormatMarkdown = "markdown" // plain markdown, Obsidian style
+	FormatLogseq   = "logseq"   // Logseq pages with key:: value properties
+	FormatMMD      = "mmd"      // MultiMarkdown "Key: Value" metadata header
+)
+
+// ValidateFormat rejects unsupported --format values.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatMarkdown, FormatLogseq, FormatMMD:
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q (want %s, %s or %s)", format, FormatMarkdown, FormatLogseq, FormatMMD)
+}
+
+// logseqPropertyRegex matches "key:: value", optionally as an outline bullet ("- key:: value").
+var logseqPropertyRegex = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z0-9_-]+)::\s*(.*)$`)
+
+// splitLogseqProperties reads the page properties at the start of a Logseq
+// page. Keys are lowercased; rest is text with the property lines removed.
+func splitLogseqProperties(text string) (props map[string]string, rest string) {
+	props = make(map[string]string)
+	lines := strings.Split(text, "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		m := logseqPropertyRegex.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		props[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+	}
+	return props, strings.Join(lines[i:], "\n")
+}
+
+// logseqList splits a property value such as "[[Project A]], b, #c" into items.
+func logseqList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		item = strings.TrimPrefix(item, "#")
+		item = strings.TrimSuffix(strings.TrimPrefix(item, "[["), "]]")
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyLogseqProperties fills fm from Logseq page properties, so title::,
+// tags:: and alias:: feed the same title and tag sources as frontmatter.
+// Frontmatter fields already set win.
+func applyLogseqProperties(fm *frontmatter, props map[string]string) {
+	if fm.Title == "" {
+		fm.Title = props["title"]
+	}
+	fm.Tags.List = append(fm.Tags.List, logseqList(props["tags"])...)
+	if len(fm.Aliases) == 0 {
+		fm.Aliases = logseqList(props["alias"])
+	}
+}
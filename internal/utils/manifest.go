@@ -0,0 +1,40 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// manifestColumns are the columns RecordManifest writes to cfg.ManifestTable.
+var manifestColumns = []string{"created_at", "root", "user_id", "files_discovered", "notes_inserted", "dry_run"}
+
+// RecordManifest inserts one audit row describing this run into cfg.ManifestTable.
+func RecordManifest(tx *sql.Tx, cfg models.Config, s models.Summary) error {
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
+	query := insertSQL(quoteIdent(cfg.ManifestTable), manifestColumns)
+	return execWrite(tx, cfg, query, now, strings.Join(RootsOf(cfg), ", "), cfg.UserID, s.Discovered, s.NotesInserted, cfg.DryRun)
+}
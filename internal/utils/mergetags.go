@@ -0,0 +1,127 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// TagLinkTables are the join tables that may point at a tag row. Those
+// missing from the schema are skipped.
+var TagLinkTables = []string{"notes_tags", "tasks_tags", "projects_tags"}
+
+// FindDuplicateTags groups the user's tags by slug (each /-separated part
+// slugified, so "Work" and "work" match but "a/b" and "ab" don't) and returns
+// a merge for every group of two or more, keeping the lowest id.
+func FindDuplicateTags(db *sql.DB, cfg models.Config) ([]models.TagMerge, error) {
+	selectSQL := fmt.Sprintf(`
+		SELECT id, %s FROM %s
+		WHERE user_id = ?
+		ORDER BY id
+	`, quoteIdent(tagsNameCol(cfg)), quoteIdent(tagsTable(cfg)))
+	rows, err := db.Query(DialectFor(cfg).Rebind(selectSQL), cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		merges []models.TagMerge
+		bySlug = make(map[string]int) // slug -> index in merges
+	)
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		slug := slugifyTag(name)[0]
+		if slug == "" {
+			continue
+		}
+		if i, ok := bySlug[slug]; ok {
+			merges[i].MergedIDs = append(merges[i].MergedIDs, id)
+			merges[i].Merged = append(merges[i].Merged, name)
+			continue
+		}
+		bySlug[slug] = len(merges)
+		merges = append(merges, models.TagMerge{KeptID: id, Kept: name})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	dupes := merges[:0]
+	for _, m := range merges {
+		if len(m.MergedIDs) > 0 {
+			dupes = append(dupes, m)
+		}
+	}
+	return dupes, nil
+}
+
+// MergeTags repoints the links of m's merged tags in each of linkTables to
+// m.KeptID, dropping links the target already has, then deletes the merged
+// tag rows. m.Repointed and m.Dropped are filled in.
+func MergeTags(tx *sql.Tx, cfg models.Config, linkTables []string, m *models.TagMerge) error {
+	for _, dup := range m.MergedIDs {
+		for _, table := range linkTables {
+			owner := ownerColumn(table)
+			deleteSQL := fmt.Sprintf(`
+				DELETE FROM %[1]s
+				WHERE tag_id = ? AND %[2]s IN (SELECT %[2]s FROM %[1]s WHERE tag_id = ?)
+			`, quoteIdent(table), quoteIdent(owner))
+			dropped, err := execCount(tx, cfg, deleteSQL, dup, m.KeptID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			updateSQL := fmt.Sprintf(`UPDATE %s SET tag_id = ? WHERE tag_id = ?`, quoteIdent(table))
+			moved, err := execCount(tx, cfg, updateSQL, m.KeptID, dup)
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			m.Dropped += int(dropped)
+			m.Repointed += int(moved)
+		}
+		deleteSQL := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, quoteIdent(tagsTable(cfg)))
+		if err := execWrite(tx, cfg, deleteSQL, dup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownerColumn is the non-tag column of a join table: note_id for notes_tags.
+func ownerColumn(table string) string {
+	switch table {
+	case "tasks_tags":
+		return "task_id"
+	case "projects_tags":
+		return "project_id"
+	}
+	return "note_id"
+}
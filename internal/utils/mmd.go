@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mmdKeyRegex matches a MultiMarkdown "Key: Value" metadata line. Keys start
+// with a letter or digit and may contain spaces, as in "Base Header Level".
+var mmdKeyRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9 _-]*):\s*(.*)$`)
+
+// mmdKnownKeys are the keys we read. A block needs at least one of them to
+// count as metadata, so a note opening with prose like "Note: call Bob"
+// keeps its first paragraph.
+var mmdKnownKeys = map[string]bool{"title": true, "tags": true, "keywords": true, "date": true}
+
+// splitMMDMetadata reads a MultiMarkdown metadata block: "Key: Value" lines
+// at the very start of text, ending at the first blank line, where indented
+// lines continue the previous value. Keys are lowercased with spaces
+// removed. ok is false, and text is returned whole, when the opening lines
+// aren't such a block, or when its Date isn't a date (as in "Date: next
+// tuesday", which reads as prose).
+func splitMMDMetadata(text string) (props map[string]string, rest string, ok bool) {
+	props = make(map[string]string)
+	lines := strings.Split(text, "\n")
+	key, known := "", false
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		if line == "" {
+			break
+		}
+		if m := mmdKeyRegex.FindStringSubmatch(line); m != nil {
+			key = strings.ToLower(strings.ReplaceAll(m[1], " ", ""))
+			props[key] = strings.TrimSpace(m[2])
+			known = known || mmdKnownKeys[key]
+			continue
+		}
+		if key != "" && (line[0] == ' ' || line[0] == '\t') {
+			props[key] = strings.TrimSpace(props[key] + " " + strings.TrimSpace(line))
+			continue
+		}
+		return nil, text, false
+	}
+	if !known {
+		return nil, text, false
+	}
+	if date := props["date"]; date != "" {
+		if _, err := parseFlexibleDate(date); err != nil {
+			return nil, text, false
+		}
+	}
+	if i < len(lines) {
+		i++ // the blank line closing the block
+	}
+	return props, strings.Join(lines[i:], "\n"), true
+}
+
+// applyMMDMetadata fills fm from MultiMarkdown metadata, so Title, Tags (or
+// Keywords) and Date feed the same title, tag and date sources as
+// frontmatter. Frontmatter fields already set win. splitMMDMetadata has
+// already checked that Date parses.
+func applyMMDMetadata(fm *frontmatter, props map[string]string) {
+	if fm.Title == "" {
+		fm.Title = props["title"]
+	}
+	fm.Tags.List = append(fm.Tags.List, logseqList(props["tags"])...)
+	fm.Tags.List = append(fm.Tags.List, logseqList(props["keywords"])...)
+	if date := props["date"]; date != "" && fm.Created.IsZero() {
+		fm.Created.Time, _ = parseFlexibleDate(date)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitMMDMetadata(t *testing.T) {
+	tests := []struct {
+		name, text string
+		props      map[string]string // nil means not a metadata block
+		rest       string
+	}{
+		{
+			name:  "header block",
+			text:  "Title: Trip Plan\nTags: travel, japan\nDate: 2023-04-01\n\nBody text.\n",
+			props: map[string]string{"title": "Trip Plan", "tags": "travel, japan", "date": "2023-04-01"},
+			rest:  "Body text.\n",
+		},
+		{
+			name:  "keys are lowercased without spaces",
+			text:  "Title: X\nBase Header Level: 2\n\nBody",
+			props: map[string]string{"title": "X", "baseheaderlevel": "2"},
+			rest:  "Body",
+		},
+		{
+			name:  "indented lines continue a value",
+			text:  "Title: A long\n    title here\nKeywords: a, b\n\nBody",
+			props: map[string]string{"title": "A long title here", "keywords": "a, b"},
+			rest:  "Body",
+		},
+		{
+			name:  "block running to the end",
+			text:  "Title: Only metadata",
+			props: map[string]string{"title": "Only metadata"},
+			rest:  "",
+		},
+		{
+			name: "prose with a colon",
+			text: "Note: call Bob about the invoice.\n\nMore text.\n",
+			rest: "Note: call Bob about the invoice.\n\nMore text.\n",
+		},
+		{
+			name: "colon line followed by prose",
+			text: "Title: Real\nthen a sentence without a key\n\nBody",
+			rest: "Title: Real\nthen a sentence without a key\n\nBody",
+		},
+		{
+			name: "heading first",
+			text: "# Heading\nTitle: Not metadata\n",
+			rest: "# Heading\nTitle: Not metadata\n",
+		},
+		{
+			name: "time of day isn't a key",
+			text: "10:30 standup\n",
+			rest: "10:30 standup\n",
+		},
+		{
+			name: "date that isn't a date",
+			text: "Date: next tuesday, after lunch\n\nBring slides.\n",
+			rest: "Date: next tuesday, after lunch\n\nBring slides.\n",
+		},
+		{
+			name: "leading blank line",
+			text: "\nTitle: Too late\n",
+			rest: "\nTitle: Too late\n",
+		},
+	}
+	for _, tt := range tests {
+		props, rest, ok := splitMMDMetadata(tt.text)
+		if ok != (tt.props != nil) || (ok && !reflect.DeepEqual(props, tt.props)) || rest != tt.rest {
+			t.Errorf("%s: got %v, %q, %v; want %v, %q", tt.name, props, rest, ok, tt.props, tt.rest)
+		}
+	}
+}
+
+func TestParseNoteMMD(t *testing.T) {
+	cfg := testConfig()
+	cfg.Format = FormatMMD
+	n := parseTestNote(t, cfg, "trip.md", "Title: Trip Plan\nTags: Travel, japan\nKeywords: food\nDate: 2023-04-01\n\n# Day one\n\n#sushi\n")
+	if n.Title != "Trip Plan" {
+		t.Errorf("title %q; want %q", n.Title, "Trip Plan")
+	}
+	if want := []string{"travel", "japan", "food", "sushi"}; !reflect.DeepEqual(n.Tags, want) {
+		t.Errorf("tags %q; want %q", n.Tags, want)
+	}
+	if want := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC); !n.CreatedAt.Equal(want) {
+		t.Errorf("created %v; want %v", n.CreatedAt, want)
+	}
+	if want := "# Day one\n\n#sushi\n"; n.Body != want {
+		t.Errorf("body %q; want %q", n.Body, want)
+	}
+
+	// Ordinary prose is left in the body, and the title falls back as usual
+	n = parseTestNote(t, cfg, "memo.md", "Reminder: renew passport\n\nMore.\n")
+	if n.Title != "memo" || n.Body != "Reminder: renew passport\n\nMore.\n" {
+		t.Errorf("prose note: title %q, body %q", n.Title, n.Body)
+	}
+
+	n = parseTestNote(t, cfg, "meeting.md", "Date: next tuesday\n\nAgenda.\n")
+	if n.Body != "Date: next tuesday\n\nAgenda.\n" {
+		t.Errorf("unparseable date: body %q", n.Body)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// normalizedFrontmatter is the block NormalizeNote writes with frontmatter on.
+type normalizedFrontmatter struct {
+	Title   string   `yaml:"title"`
+	Tags    []string `yaml:"tags,omitempty"`
+	Created string   `yaml:"created"`
+	Updated string   `yaml:"updated"`
+}
+
+// NormalizeNote serializes n back to markdown: a "# Title" heading (replacing
+// a leading heading that repeats the title), then the body with trailing
+// whitespace trimmed outside code fences and leading/trailing blank lines
+// dropped. With frontmatter, a YAML block holding the resolved title, tags
+// and dates comes first.
+func NormalizeNote(n models.Note, frontmatter bool) (string, error) {
+	var b strings.Builder
+	if frontmatter {
+		fm, err := yaml.Marshal(normalizedFrontmatter{
+			Title:   n.Title,
+			Tags:    UniqueStrings(n.Tags),
+			Created: n.CreatedAt.UTC().Format(time.RFC3339),
+			Updated: n.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("---\n")
+		b.Write(fm)
+		b.WriteString("---\n")
+	}
+
+	body := trimLineEnds(n.Body)
+	body = strings.Trim(body, "\n")
+	first, rest, _ := strings.Cut(body, "\n")
+	if headingRegex.MatchString(first) && strings.TrimSpace(strings.TrimLeft(first, "#")) == n.Title {
+		body = strings.TrimLeft(rest, "\n")
+	}
+
+	b.WriteString("# " + n.Title + "\n")
+	if body != "" {
+		b.WriteString("\n" + body + "\n")
+	}
+	return b.String(), nil
+}
+
+// trimLineEnds strips trailing whitespace from every line outside fenced
+// code blocks.
+func trimLineEnds(text string) string {
+	lines := strings.Split(text, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if marker := fenceMarker(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(marker, fence) && strings.TrimLeft(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if fence == "" {
+			lines[i] = strings.TrimRight(line, " \t\r")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// NoteIndex maps the names a [[wikilink]] may use for a note (title, file
+// name, or path relative to Root, all without .md and case-insensitive) to
+// the note's id. The first note to claim a name keeps it.
+type NoteIndex map[string]int64
+
+// Add registers the names of note n, stored under id.
+func (idx NoteIndex) Add(n models.Note, id int64) {
+	rel := strings.TrimSuffix(filepath.ToSlash(n.RelPath), filepath.Ext(n.RelPath))
+	for _, name := range []string{n.Title, titleFromFilename(n.Path), rel} {
+		if key := noteKey(name); key != "" {
+			if _, taken := idx[key]; !taken {
+				idx[key] = id
+			}
+		}
+	}
+}
+
+// Resolve returns the id of the note a wikilink target points at.
+func (idx NoteIndex) Resolve(target string) (int64, bool) {
+	id, ok := idx[noteKey(strings.TrimSuffix(target, ".md"))]
+	return id, ok
+}
+
+func noteKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// LinkNotes inserts a note_links row from sourceID to targetID, ignoring duplicates.
+func LinkNotes(tx *sql.Tx, cfg models.Config, sourceID, targetID int64) error {
+	insertSQL := DialectFor(cfg).InsertOrIgnore(`
+		INSERT INTO note_links (source_note_id, target_note_id)
+		VALUES (?, ?)
+	`)
+	return execWrite(tx, cfg, insertSQL, sourceID, targetID)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// DefaultPragmas are applied to SQLite connections before any --pragma
+// entries: WAL lets Tududi keep reading while we write, and NORMAL
+// synchronous is safe under WAL and much faster for bulk inserts.
+var DefaultPragmas = []string{"journal_mode=WAL", "synchronous=NORMAL"}
+
+var (
+	pragmaNameRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	pragmaValueRegex = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+)
+
+// ValidatePragmas rejects --pragma entries that aren't key=value with a plain
+// pragma name and value, since both end up in the PRAGMA statement verbatim.
+func ValidatePragmas(pragmas []string) error {
+	for _, p := range pragmas {
+		if _, _, err := splitPragma(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitPragma(p string) (name, value string, err error) {
+	name, value, ok := strings.Cut(p, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || !pragmaNameRegex.MatchString(name) || !pragmaValueRegex.MatchString(value) {
+		return "", "", fmt.Errorf("invalid pragma %q (want key=value, e.g. journal_mode=WAL)", p)
+	}
+	return strings.ToLower(name), value, nil
+}
+
+// ApplyPragmas sets busy_timeout (from cfg.BusyTimeout) on a SQLite db and,
+// when writes is set, DefaultPragmas and cfg.Pragmas around it, later entries
+// winning for the same pragma. journal_mode sticks to the database file, so
+// runs that don't commit anything (dry runs, read-only commands) leave it
+// alone. It returns the effective values read back as name=value. Postgres
+// has no pragmas, so it returns nil.
+func ApplyPragmas(db *sql.DB, cfg models.Config, writes bool) ([]string, error) {
+	if cfg.Driver == DriverPostgres {
+		return nil, nil
+	}
+	all := []string{"busy_timeout=" + strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10)}
+	if writes {
+		all = append(append(append([]string{}, DefaultPragmas...), all...), cfg.Pragmas...)
+	}
+
+	var names []string
+	values := make(map[string]string)
+	for _, p := range all {
+		name, value, err := splitPragma(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, seen := values[name]; !seen {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+
+	effective := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA %s = %s", name, values[name])); err != nil {
+			return nil, fmt.Errorf("pragma %s: %w", name, err)
+		}
+		value := values[name]
+		var current string
+		if err := db.QueryRow("PRAGMA " + name).Scan(&current); err == nil {
+			value = current
+		}
+		effective = append(effective, name+"="+value)
+	}
+	return effective, nil
+}
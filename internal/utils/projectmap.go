@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// LoadProjectMap reads a folder-path -> project-id mapping from a JSON object
+// or a two-column CSV file (chosen by extension). Folder paths are relative to
+// Root and stored slash-separated without leading or trailing slashes.
+func LoadProjectMap(path string) (map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var ids map[string]int
+		if err := json.Unmarshal(data, &ids); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		for folder, id := range ids {
+			raw[folder] = strconv.Itoa(id)
+		}
+	} else {
+		r := csv.NewReader(strings.NewReader(string(data)))
+		r.Comment = '#'
+		r.FieldsPerRecord = 2
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		for _, rec := range records {
+			raw[rec[0]] = rec[1]
+		}
+	}
+
+	projectMap := make(map[string]int, len(raw))
+	for folder, id := range raw {
+		key := projectMapKey(folder)
+		if key == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("parse %s: folder %q: invalid project id %q", path, folder, id)
+		}
+		projectMap[key] = n
+	}
+	return projectMap, nil
+}
+
+func projectMapKey(folder string) string {
+	folder = strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/")
+	if folder == "" {
+		return ""
+	}
+	return pathpkg.Clean(folder)
+}
+
+// MappedProject returns the project id cfg.ProjectMap gives the note's folder,
+// or that of its nearest mapped parent folder. ok is false when none is mapped.
+func MappedProject(cfg models.Config, n models.Note) (id int, ok bool) {
+	dir := pathpkg.Dir(filepath.ToSlash(n.RelPath))
+	for dir != "." && dir != "/" && dir != "" {
+		if id, ok := cfg.ProjectMap[dir]; ok {
+			return id, true
+		}
+		dir = pathpkg.Dir(dir)
+	}
+	return 0, false
+}
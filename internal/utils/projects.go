@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// TopLevelFolder returns the first folder of the note's path under Root, or ""
+// when the note sits directly in Root.
+func TopLevelFolder(n models.Note) string {
+	dir := filepath.Dir(n.RelPath)
+	if dir == "." || dir == "" {
+		return ""
+	}
+	return strings.Split(dir, string(os.PathSeparator))[0]
+}
+
+// GetOrCreateProject returns an existing project id or creates a new one if needed.
+// New projects are placed in areaID when it is positive; existing ones are left as-is.
+func GetOrCreateProject(tx *sql.Tx, cfg models.Config, cache *IDCache, name string, areaID int64) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("empty project name")
+	}
+
+	cacheKey := fmt.Sprintf("%s|%d", name, cfg.UserID)
+	id, _, err := cache.GetOrCreate(cacheKey, func() (int64, bool, error) { return findOrInsertProject(tx, cfg, name, areaID) })
+	return id, err
+}
+
+// findOrInsertProject looks up the user's project called name, inserting it
+// (in areaID, when positive) if missing.
+func findOrInsertProject(tx *sql.Tx, cfg models.Config, name string, areaID int64) (id int64, created bool, err error) {
+	// Try to find existing project for this user
+	selectSQL := `
+		SELECT id FROM projects
+		WHERE name = ? AND user_id = ?
+		LIMIT 1
+	`
+	d := DialectFor(cfg)
+	var existingID int64
+	err = RetryBusy(cfg, func() error { return tx.QueryRow(d.Rebind(selectSQL), name, cfg.UserID).Scan(&existingID) })
+	if err == nil {
+		return existingID, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	// Insert new project
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
+	uid, err := GenerateID(cfg)
+	if err != nil {
+		return 0, false, err
+	}
+
+	insertSQL := `
+		INSERT INTO projects (uid, name, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	args := []interface{}{uid, name, cfg.UserID, now, now}
+	if areaID > 0 {
+		insertSQL = `
+			INSERT INTO projects (uid, name, user_id, area_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`
+		args = []interface{}{uid, name, cfg.UserID, areaID, now, now}
+	}
+	newID, err := insertID(tx, cfg, insertSQL, args...)
+	if err != nil {
+		return 0, false, err
+	}
+	return newID, true, nil
+}
+
+// GetOrCreateArea returns an existing area id or creates a new one if needed.
+func GetOrCreateArea(tx *sql.Tx, cfg models.Config, cache *IDCache, name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("empty area name")
+	}
+
+	cacheKey := fmt.Sprintf("%s|%d", name, cfg.UserID)
+	id, _, err := cache.GetOrCreate(cacheKey, func() (int64, bool, error) { return findOrInsertArea(tx, cfg, name) })
+	return id, err
+}
+
+// findOrInsertArea looks up the user's area called name, inserting it if missing.
+func findOrInsertArea(tx *sql.Tx, cfg models.Config, name string) (id int64, created bool, err error) {
+	// Try to find existing area for this user
+	selectSQL := `
+		SELECT id FROM areas
+		WHERE name = ? AND user_id = ?
+		LIMIT 1
+	`
+	d := DialectFor(cfg)
+	var existingID int64
+	err = RetryBusy(cfg, func() error { return tx.QueryRow(d.Rebind(selectSQL), name, cfg.UserID).Scan(&existingID) })
+	if err == nil {
+		return existingID, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	// Insert new area
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
+	uid, err := GenerateID(cfg)
+	if err != nil {
+		return 0, false, err
+	}
+
+	insertSQL := `
+		INSERT INTO areas (uid, name, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	newID, err := insertID(tx, cfg, insertSQL, uid, name, cfg.UserID, now, now)
+	if err != nil {
+		return 0, false, err
+	}
+	return newID, true, nil
+}
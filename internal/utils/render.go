@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sottey/tududimport/internal/models"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Supported --html-extensions values
+const (
+	HTMLTable         = "table"
+	HTMLStrikethrough = "strikethrough"
+	HTMLTaskList      = "tasklist"
+	HTMLLinkify       = "linkify"
+	HTMLGFM           = "gfm" // all of the above
+)
+
+var htmlExtensions = map[string]goldmark.Extender{
+	HTMLTable:         extension.Table,
+	HTMLStrikethrough: extension.Strikethrough,
+	HTMLTaskList:      extension.TaskList,
+	HTMLLinkify:       extension.Linkify,
+	HTMLGFM:           extension.GFM,
+}
+
+// ValidateHTMLExtensions rejects unknown --html-extensions entries.
+func ValidateHTMLExtensions(names []string) error {
+	for _, name := range names {
+		if _, ok := htmlExtensions[name]; !ok {
+			return fmt.Errorf("unsupported HTML extension %q (want %s, %s, %s, %s or %s)",
+				name, HTMLTable, HTMLStrikethrough, HTMLTaskList, HTMLLinkify, HTMLGFM)
+		}
+	}
+	return nil
+}
+
+// goldmarkRenderer renders CommonMark, plus any configured extensions, with goldmark.
+type goldmarkRenderer struct {
+	md goldmark.Markdown
+}
+
+// NewHTMLRenderer returns a goldmark-based renderer with the named extensions
+// enabled. Raw HTML inside the markdown is omitted, as goldmark does by default.
+func NewHTMLRenderer(extensions []string) (models.Renderer, error) {
+	if err := ValidateHTMLExtensions(extensions); err != nil {
+		return nil, err
+	}
+	var exts []goldmark.Extender
+	for _, name := range extensions {
+		exts = append(exts, htmlExtensions[name])
+	}
+	return goldmarkRenderer{md: goldmark.New(goldmark.WithExtensions(exts...))}, nil
+}
+
+func (r goldmarkRenderer) Render(markdown string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderNotes replaces each note body with cfg.Renderer's output. It runs
+// after titles, tags and tasks have been taken from the raw markdown, and
+// after dedupe has merged bodies.
+func renderNotes(cfg models.Config, notes []models.Note) error {
+	if cfg.Renderer == nil {
+		return nil
+	}
+	for i := range notes {
+		body, err := cfg.Renderer.Render(notes[i].Body)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", notes[i].Path, err)
+		}
+		notes[i].Body = body
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// NewReportEntry describes what the importer did (or would do) with a note.
+func NewReportEntry(n models.Note, action string) models.ReportEntry {
+	tags := UniqueStrings(n.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return models.ReportEntry{
+		Path:      n.Path,
+		Title:     n.Title,
+		Tags:      tags,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+		Action:    action,
+	}
+}
+
+// WriteReportJSON writes the report entries to path as indented JSON.
+func WriteReportJSON(path string, entries []models.ReportEntry) error {
+	if entries == nil {
+		entries = []models.ReportEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// WriteErrorFile writes the failed entries among entries to path as CSV
+// with a path,error header row.
+func WriteErrorFile(path string, entries []models.ReportEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"path", "error"})
+	for _, e := range entries {
+		if e.Action == models.ActionFail {
+			_ = w.Write([]string{e.Path, e.Error})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteReportCSV writes the report entries to path as CSV with a header row;
+// tags are joined with semicolons.
+func WriteReportCSV(path string, entries []models.ReportEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"path", "title", "tags", "created", "updated", "action"})
+	for _, e := range entries {
+		_ = w.Write([]string{
+			e.Path,
+			e.Title,
+			strings.Join(e.Tags, ";"),
+			e.CreatedAt.Format(time.RFC3339),
+			e.UpdatedAt.Format(time.RFC3339),
+			e.Action,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
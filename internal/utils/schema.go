@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// TableColumns returns the set of column names for a table. A missing table
+// yields an empty set.
+func TableColumns(db *sql.DB, cfg models.Config, table string) (map[string]bool, error) {
+	return DialectFor(cfg).TableColumns(db, table)
+}
+
+// HasColumn reports whether table has the named column.
+func HasColumn(db *sql.DB, cfg models.Config, table, column string) (bool, error) {
+	cols, err := TableColumns(db, cfg, table)
+	if err != nil {
+		return false, err
+	}
+	return cols[column], nil
+}
+
+// Default table and column names, overridable for forks that renamed them
+const (
+	DefaultTagsTable       = "tags"
+	DefaultTagsNameCol     = "name"
+	DefaultNotesContentCol = "content"
+)
+
+// tagsTable returns cfg.TagsTable, or the default when unset.
+func tagsTable(cfg models.Config) string {
+	if cfg.TagsTable == "" {
+		return DefaultTagsTable
+	}
+	return cfg.TagsTable
+}
+
+// tagsNameCol returns cfg.TagsNameCol, or the default when unset.
+func tagsNameCol(cfg models.Config) string {
+	if cfg.TagsNameCol == "" {
+		return DefaultTagsNameCol
+	}
+	return cfg.TagsNameCol
+}
+
+// notesContentCol returns cfg.NotesContentCol, or the default when unset.
+func notesContentCol(cfg models.Config) string {
+	if cfg.NotesContentCol == "" {
+		return DefaultNotesContentCol
+	}
+	return cfg.NotesContentCol
+}
+
+// ValidateSchema checks that the tables and columns the import will write to
+// exist, returning an error that lists everything missing.
+func ValidateSchema(db *sql.DB, cfg models.Config) error {
+	required := map[string][]string{
+		"notes":        {"uid", "title", notesContentCol(cfg), "user_id", "created_at", "updated_at"},
+		tagsTable(cfg): {"uid", tagsNameCol(cfg), "user_id", "created_at", "updated_at"},
+		"notes_tags":   {"note_id", "tag_id", "created_at", "updated_at"},
+	}
+	projects := cfg.ProjectID >= 0 || cfg.ProjectFromFolder || len(cfg.ProjectMap) > 0
+	if projects {
+		required["notes"] = append(required["notes"], "project_id")
+	}
+	if cfg.ProjectFromFolder {
+		required["projects"] = []string{"uid", "name", "user_id", "created_at", "updated_at"}
+		if cfg.Area != "" {
+			required["projects"] = append(required["projects"], "area_id")
+			required["areas"] = []string{"uid", "name", "user_id", "created_at", "updated_at"}
+		}
+	}
+
+	if cfg.ImportTasks {
+		required["tasks"] = []string{"uid", "name", "status", "completed_at", "user_id", "created_at", "updated_at"}
+		if projects {
+			required["tasks"] = append(required["tasks"], "project_id")
+		}
+	}
+
+	if cfg.ImportNoteLinks {
+		required["note_links"] = []string{"source_note_id", "target_note_id"}
+	}
+
+	tables := make([]string, 0, len(required))
+	for table := range required {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var missing []string
+	for _, table := range tables {
+		cols, err := TableColumns(db, cfg, table)
+		if err != nil {
+			return fmt.Errorf("inspect %s: %w", table, err)
+		}
+		if len(cols) == 0 {
+			missing = append(missing, "table "+table)
+			continue
+		}
+		for _, col := range required[table] {
+			if !cols[col] {
+				missing = append(missing, "column "+table+"."+col)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		err := fmt.Errorf("database does not look like a Tududi schema, missing: %s", strings.Join(missing, ", "))
+		contentCol := "column notes." + notesContentCol(cfg)
+		for _, m := range missing {
+			if m == contentCol {
+				return fmt.Errorf("%w (use --notes-content-col if note bodies are stored in another column)", err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// quoteIdent quotes a SQLite identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.OversizeAction
+const (
+	OversizeSkip     = "skip"     // leave files over MaxBodySize out of the import
+	OversizeTruncate = "truncate" // import only the first MaxBodySize bytes
+)
+
+// ValidateOversizeAction rejects unsupported --oversize-action values.
+func ValidateOversizeAction(action string) error {
+	switch action {
+	case OversizeSkip, OversizeTruncate:
+		return nil
+	}
+	return fmt.Errorf("unsupported oversize action %q (want %s or %s)", action, OversizeSkip, OversizeTruncate)
+}
+
+// keepSized reports whether a file of the given size should be parsed,
+// warning about every file over cfg.MaxBodySize.
+func keepSized(cfg models.Config, path string, size int64, warn func(path, msg string)) bool {
+	if cfg.MaxBodySize <= 0 || size <= cfg.MaxBodySize {
+		return true
+	}
+	if cfg.OversizeAction == OversizeTruncate {
+		warn(path, fmt.Sprintf("truncating %s: %d bytes exceeds --max-body-size %d", path, size, cfg.MaxBodySize))
+		return true
+	}
+	warn(path, fmt.Sprintf("skipping %s: %d bytes exceeds --max-body-size %d", path, size, cfg.MaxBodySize))
+	return false
+}
+
+// readLimited reads at most limit bytes of r (all of it when limit <= 0),
+// dropping a multi-byte character cut off at the end.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) == limit {
+		for i := 0; i < utf8.UTFMax-1 && len(data) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size != 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+	}
+	return data, nil
+}
+
+// readFileLimited is os.ReadFile bounded by readLimited.
+func readFileLimited(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readLimited(f, limit)
+}
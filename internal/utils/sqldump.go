@@ -0,0 +1,208 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SQLDump writes the statements an import executes to a .sql file, with
+// their arguments inlined as literals, so they can be reviewed or replayed
+// by hand. It implements models.SQLRecorder. Statements are held back until
+// the importer reports their transaction committed (or, in dry-run, would
+// have), so work that was rolled back never reaches the file.
+type SQLDump struct {
+	f       *os.File
+	w       *bufio.Writer
+	driver  string
+	pending []string // recorded since the last Commit
+	idTabs  []string // tables that got explicit ids, for resetting Postgres sequences
+	err     error    // first write error; later writes are dropped
+	closed  bool
+}
+
+// NewSQLDump creates path and writes the dump header. Statements are
+// wrapped in a single transaction.
+func NewSQLDump(path, driver string) (*SQLDump, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &SQLDump{f: f, w: bufio.NewWriter(f), driver: driver}
+	_, d.err = fmt.Fprintf(d.w, "-- Written by tududimport at %s\n-- New rows get the ids the database assigned during that run, so replaying fails\n-- rather than mislinking rows when those ids are already taken.\nBEGIN;\n",
+		time.Now().UTC().Format(time.RFC3339))
+	return d, nil
+}
+
+// insertIDRegex matches the start of an insert given an explicit id by
+// withInsertedID, once inlined.
+var insertIDRegex = regexp.MustCompile(`^INSERT INTO (\S+) \(id, `)
+
+// Record holds query, with args inlined, until the next Commit.
+func (d *SQLDump) Record(query string, args ...interface{}) {
+	if d.err != nil || d.closed {
+		return
+	}
+	d.pending = append(d.pending, inlineSQLArgs(query, args, d.driver))
+}
+
+// Savepoint returns a mark for RollbackTo.
+func (d *SQLDump) Savepoint() int {
+	return len(d.pending)
+}
+
+// RollbackTo drops the statements recorded since mark was taken.
+func (d *SQLDump) RollbackTo(mark int) {
+	if mark < len(d.pending) {
+		d.pending = d.pending[:mark]
+	}
+}
+
+// Rollback drops every statement recorded since the last Commit.
+func (d *SQLDump) Rollback() {
+	d.pending = d.pending[:0]
+}
+
+// Commit writes the statements recorded since the last Commit.
+func (d *SQLDump) Commit() {
+	for _, stmt := range d.pending {
+		if d.err != nil {
+			break
+		}
+		if m := insertIDRegex.FindStringSubmatch(stmt); m != nil && !containsString(d.idTabs, m[1]) {
+			d.idTabs = append(d.idTabs, m[1])
+		}
+		_, d.err = fmt.Fprintf(d.w, "%s;\n", stmt)
+	}
+	d.pending = d.pending[:0]
+}
+
+// Close ends the transaction and closes the file, returning the first error
+// seen while writing. Statements never committed are dropped. On Postgres,
+// sequences of tables given explicit ids are moved past them first. Closing
+// twice is a no-op.
+func (d *SQLDump) Close() error {
+	if d.closed {
+		return d.err
+	}
+	d.closed = true
+	if d.driver == DriverPostgres {
+		for _, table := range d.idTabs {
+			if d.err != nil {
+				break
+			}
+			_, d.err = fmt.Fprintf(d.w, "SELECT setval(pg_get_serial_sequence(%s, 'id'), (SELECT MAX(id) FROM %s));\n",
+				quoteSQLString(table), table)
+		}
+	}
+	if d.err == nil {
+		_, d.err = d.w.WriteString("COMMIT;\n")
+	}
+	if err := d.w.Flush(); d.err == nil {
+		d.err = err
+	}
+	if err := d.f.Close(); d.err == nil {
+		d.err = err
+	}
+	return d.err
+}
+
+// withInsertedID adds an id column set to id to an
+// "INSERT INTO table (cols) VALUES (...)" statement, so a replayed dump
+// creates the row under the id later statements refer to.
+func withInsertedID(query string, args []interface{}, id int64) (string, []interface{}) {
+	open := strings.IndexByte(query, '(')
+	values := strings.Index(query, "VALUES (")
+	if open < 0 || values < open {
+		return query, args
+	}
+	values += len("VALUES (")
+	query = query[:open+1] + "id, " + query[open+1:values] + "?, " + query[values:]
+	return query, append([]interface{}{id}, args...)
+}
+
+// containsString reports whether list holds s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineSQLArgs replaces each ? placeholder outside quoted strings in query
+// with the matching argument as a SQL literal, and puts the statement on one line.
+func inlineSQLArgs(query string, args []interface{}, driver string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	var (
+		b       strings.Builder
+		quoted  bool
+		nextArg int
+	)
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted && nextArg < len(args):
+			b.WriteString(sqlLiteral(args[nextArg], driver))
+			nextArg++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlLiteral formats v as a SQL literal for driver.
+func sqlLiteral(v interface{}, driver string) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if driver == DriverPostgres {
+			return strings.ToUpper(fmt.Sprint(v))
+		}
+		if v {
+			return "1"
+		}
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return quoteSQLString(v.UTC().Format("2006-01-02 15:04:05.000 +00:00"))
+	case []byte:
+		return quoteSQLString(string(v))
+	default:
+		return quoteSQLString(fmt.Sprint(v))
+	}
+}
+
+// quoteSQLString quotes s as a SQL string literal, doubling embedded quotes.
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
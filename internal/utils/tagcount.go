@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"sort"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// CountTags returns each distinct tag on notes with the number of notes
+// carrying it, most used first and then by name.
+func CountTags(notes []models.Note) []models.TagCount {
+	counts := make(map[string]int)
+	for _, n := range notes {
+		for _, t := range UniqueStrings(n.Tags) {
+			counts[t]++
+		}
+	}
+	tags := make([]models.TagCount, 0, len(counts))
+	for name, c := range counts {
+		tags = append(tags, models.TagCount{Name: name, Notes: c})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Notes != tags[j].Notes {
+			return tags[i].Notes > tags[j].Notes
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
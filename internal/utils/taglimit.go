@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.LongTagAction
+const (
+	LongTagTruncate = "truncate" // keep the first MaxTagLength characters
+	LongTagSkip     = "skip"     // drop the tag
+)
+
+// ValidateLongTagAction rejects unsupported --long-tag-action values.
+func ValidateLongTagAction(action string) error {
+	switch action {
+	case LongTagTruncate, LongTagSkip:
+		return nil
+	}
+	return fmt.Errorf("unsupported long tag action %q (want %s or %s)", action, LongTagTruncate, LongTagSkip)
+}
+
+// dropShortTags drops tags shorter than cfg.MinTagLength characters, warning
+// once per distinct tag. --add-tag values are kept whatever their length.
+func dropShortTags(cfg models.Config, notes []models.Note, warn func(path, msg string)) {
+	if cfg.MinTagLength <= 1 {
+		return
+	}
+	extra := make(map[string]bool)
+	for _, t := range cfg.ExtraTags {
+		extra[slugify(t)] = true
+	}
+	warned := make(map[string]bool)
+	for i := range notes {
+		kept := notes[i].Tags[:0]
+		for _, t := range notes[i].Tags {
+			if extra[t] || utf8.RuneCountInString(t) >= cfg.MinTagLength {
+				kept = append(kept, t)
+				continue
+			}
+			if !warned[t] {
+				warned[t] = true
+				warn(notes[i].Path, fmt.Sprintf("skipping tag %q: shorter than --tag-min-length %d", t, cfg.MinTagLength))
+			}
+		}
+		notes[i].Tags = kept
+	}
+}
+
+// limitTagLength truncates or drops tags longer than cfg.MaxTagLength
+// characters, warning once per distinct tag.
+func limitTagLength(cfg models.Config, notes []models.Note, warn func(path, msg string)) {
+	if cfg.MaxTagLength <= 0 {
+		return
+	}
+	warned := make(map[string]bool)
+	for i := range notes {
+		kept := notes[i].Tags[:0]
+		for _, t := range notes[i].Tags {
+			if utf8.RuneCountInString(t) <= cfg.MaxTagLength {
+				kept = append(kept, t)
+				continue
+			}
+			short := string([]rune(t)[:cfg.MaxTagLength])
+			if !warned[t] {
+				warned[t] = true
+				if cfg.LongTagAction == LongTagSkip {
+					warn(notes[i].Path, fmt.Sprintf("skipping tag %q: longer than --max-tag-length %d", t, cfg.MaxTagLength))
+				} else {
+					warn(notes[i].Path, fmt.Sprintf("truncating tag %q to %q: longer than --max-tag-length %d", t, short, cfg.MaxTagLength))
+				}
+			}
+			if cfg.LongTagAction != LongTagSkip {
+				kept = append(kept, short)
+			}
+		}
+		notes[i].Tags = kept
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+func TestDropShortTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		min    int
+		extra  []string
+		tags   []string
+		want   []string
+		warned int
+	}{
+		{"default drops single characters", 2, nil, []string{"a", "ab", "x", "work"}, []string{"ab", "work"}, 2},
+		{"counts characters, not bytes", 2, nil, []string{"é", "日", "日記"}, []string{"日記"}, 2},
+		{"higher minimum", 4, nil, []string{"ab", "abc", "abcd"}, []string{"abcd"}, 2},
+		{"0 keeps all", 0, nil, []string{"a", "b"}, []string{"a", "b"}, 0},
+		{"1 keeps all", 1, nil, []string{"a", "b"}, []string{"a", "b"}, 0},
+		{"--add-tag values are kept", 3, []string{"Q1"}, []string{"q1", "ab", "abc"}, []string{"q1", "abc"}, 1},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.MinTagLength = tt.min
+		cfg.ExtraTags = tt.extra
+		notes := []models.Note{{Tags: append([]string{}, tt.tags...)}}
+		var warnings []string
+		dropShortTags(cfg, notes, func(_, msg string) { warnings = append(warnings, msg) })
+		if !reflect.DeepEqual(notes[0].Tags, tt.want) {
+			t.Errorf("%s: tags %q; want %q", tt.name, notes[0].Tags, tt.want)
+		}
+		if len(warnings) != tt.warned {
+			t.Errorf("%s: %d warnings %q; want %d", tt.name, len(warnings), warnings, tt.warned)
+		}
+	}
+}
+
+func TestDropShortTagsAppliesToEverySource(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "x", "projects")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\ntags: [y, meeting]\n---\n# Note\n\n#a #include-ish #z\n"
+	if err := os.WriteFile(filepath.Join(dir, "note.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.Root = root
+	cfg.Frontmatter = FrontmatterParse
+	notes, stats, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 {
+		t.Fatalf("discovered %d notes; want 1", len(notes))
+	}
+	if want := []string{"meeting", "include-ish", "projects"}; !reflect.DeepEqual(notes[0].Tags, want) {
+		t.Errorf("tags %q; want %q", notes[0].Tags, want)
+	}
+	if len(stats.Warnings) != 4 {
+		t.Errorf("warnings %v; want one per dropped tag", stats.Warnings)
+	}
+	for _, w := range stats.Warnings {
+		if w.Path != notes[0].Path {
+			t.Errorf("warning %q has path %q; want %q", w.Msg, w.Path, notes[0].Path)
+		}
+	}
+}
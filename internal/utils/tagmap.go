@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadTagMap reads a folder-slug -> tag-name mapping from a JSON object or a
+// two-column CSV file (chosen by extension). Keys are slugified, so raw folder
+// names work too.
+func LoadTagMap(path string) (map[string]string, error) {
+	raw, err := readStringMap(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tagMap := make(map[string]string, len(raw))
+	for folder, tag := range raw {
+		folder, tag = slugify(folder), strings.TrimSpace(tag)
+		if folder != "" && tag != "" {
+			tagMap[folder] = tag
+		}
+	}
+	return tagMap, nil
+}
+
+// readStringMap reads a string -> string mapping from a JSON object or a
+// two-column CSV file (chosen by extension).
+func readStringMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	} else {
+		r := csv.NewReader(strings.NewReader(string(data)))
+		r.Comment = '#'
+		r.FieldsPerRecord = 2
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		for _, rec := range records {
+			raw[rec[0]] = rec[1]
+		}
+	}
+	return raw, nil
+}
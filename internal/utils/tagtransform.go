@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported --tag-transform names. strip-prefix and synonym-map take an
+// argument after "=", e.g. strip-prefix=topic/ or synonym-map=synonyms.csv.
+const (
+	TransformLowercase   = "lowercase"    // lower-case the tag
+	TransformSlugify     = "slugify"      // slugify each /-separated part
+	TransformStripPrefix = "strip-prefix" // remove a leading prefix
+	TransformSynonymMap  = "synonym-map"  // rename tags listed in a CSV or JSON file
+)
+
+// ValidateTagTransforms rejects --tag-transform entries with an unknown name
+// or a missing argument. Synonym files are read later, by DiscoverNotes.
+func ValidateTagTransforms(specs []string) error {
+	for _, spec := range specs {
+		if _, _, err := splitTransform(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitTransform(spec string) (name, arg string, err error) {
+	name, arg, _ = strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	switch name {
+	case TransformLowercase, TransformSlugify:
+		return name, "", nil
+	case TransformStripPrefix, TransformSynonymMap:
+		if arg == "" {
+			return "", "", fmt.Errorf("tag transform %s needs an argument, e.g. %s=value", name, name)
+		}
+		return name, arg, nil
+	}
+	return "", "", fmt.Errorf("unsupported tag transform %q (want %s, %s, %s=prefix or %s=file)",
+		name, TransformLowercase, TransformSlugify, TransformStripPrefix, TransformSynonymMap)
+}
+
+// BuildTagTransforms turns --tag-transform specs into the chain applied by
+// applyTagTransforms, loading any synonym-map files.
+func BuildTagTransforms(specs []string) ([]models.TagTransform, error) {
+	chain := make([]models.TagTransform, 0, len(specs))
+	for _, spec := range specs {
+		name, arg, err := splitTransform(spec)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case TransformLowercase:
+			chain = append(chain, func(t string) []string { return []string{strings.ToLower(t)} })
+		case TransformSlugify:
+			chain = append(chain, slugifyTag)
+		case TransformStripPrefix:
+			prefix := arg
+			chain = append(chain, func(t string) []string { return []string{strings.TrimPrefix(t, prefix)} })
+		case TransformSynonymMap:
+			synonyms, err := readStringMap(arg)
+			if err != nil {
+				return nil, fmt.Errorf("load synonym map: %w", err)
+			}
+			chain = append(chain, func(t string) []string {
+				if to, ok := synonyms[t]; ok {
+					return []string{to}
+				}
+				return []string{t}
+			})
+		}
+	}
+	return chain, nil
+}
+
+// slugifyTag is tagSlug as a TagTransform.
+func slugifyTag(t string) []string {
+	return []string{tagSlug(t)}
+}
+
+// applyTagTransforms runs each tag through chain in order. A transform may
+// return several tags, each fed to the next step, or none to drop the tag;
+// empty results are dropped too.
+func applyTagTransforms(chain []models.TagTransform, tags []string) []string {
+	for _, transform := range chain {
+		var next []string
+		for _, t := range tags {
+			for _, out := range transform(t) {
+				if out = strings.TrimSpace(out); out != "" {
+					next = append(next, out)
+				}
+			}
+		}
+		tags = next
+	}
+	return tags
+}
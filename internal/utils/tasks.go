@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Tududi task status values
+const (
+	taskStatusNotStarted = 0
+	taskStatusDone       = 2
+)
+
+// taskRegex matches GitHub-style task list items: "- [ ] todo", "* [x] done"
+var taskRegex = regexp.MustCompile(`(?m)^[ \t]*[-*+][ \t]+\[([ xX])\][ \t]+(.+?)[ \t]*$`)
+
+// extractTasks returns the checkbox items in text with the list/checkbox syntax stripped.
+func extractTasks(text string) []models.Task {
+	var tasks []models.Task
+	for _, m := range taskRegex.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimSpace(m[2])
+		if name == "" {
+			continue
+		}
+		tasks = append(tasks, models.Task{
+			Name:      name,
+			Completed: m[1] != " ",
+		})
+	}
+	return tasks
+}
+
+// InsertTask inserts a task for the note's user and project. When the tasks
+// table has a note_id column (cfg.TaskNoteID) the task is linked to the note too.
+func InsertTask(tx *sql.Tx, cfg models.Config, noteID int64, task models.Task) (int64, error) {
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
+
+	status := taskStatusNotStarted
+	var completedAt interface{}
+	if task.Completed {
+		status = taskStatusDone
+		completedAt = now
+	}
+
+	uid, err := GenerateID(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	cols := []string{"uid", "name", "status", "completed_at", "user_id"}
+	args := []interface{}{uid, task.Name, status, completedAt, cfg.UserID}
+
+	if cfg.ProjectID >= 0 {
+		cols = append(cols, "project_id")
+		args = append(args, cfg.ProjectID)
+	}
+	if cfg.TaskNoteID {
+		cols = append(cols, "note_id")
+		args = append(args, noteID)
+	}
+
+	cols = append(cols, "created_at", "updated_at")
+	args = append(args, now, now)
+
+	return insertID(tx, cfg, insertSQL("tasks", cols), args...)
+}
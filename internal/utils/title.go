@@ -0,0 +1,166 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported --title-source values
+const (
+	TitleFromFrontmatter = "frontmatter" // frontmatter title:
+	TitleFromH1          = "h1"          // first "# " heading
+	TitleFromH2          = "h2"          // first "## " heading
+	TitleFromDataview    = "dataview"    // first "title:: ..." inline field
+	TitleFromAliases     = "aliases"     // first frontmatter aliases: entry
+	TitleFromFilename    = "filename"    // file name without extension
+)
+
+// DefaultTitleSources is the fallback chain used when --title-source isn't set:
+// frontmatter title:, then the first "# " heading, then the first frontmatter
+// alias, then the file name.
+var DefaultTitleSources = []string{TitleFromFrontmatter, TitleFromH1, TitleFromAliases, TitleFromFilename}
+
+var dataviewTitleRegex = regexp.MustCompile(`(?i)^title::\s*(.+)$`)
+
+// ValidateTitleSources rejects unknown --title-source entries.
+func ValidateTitleSources(sources []string) error {
+	for _, s := range sources {
+		switch s {
+		case TitleFromFrontmatter, TitleFromH1, TitleFromH2, TitleFromDataview, TitleFromAliases, TitleFromFilename:
+		default:
+			return fmt.Errorf("unsupported title source %q", s)
+		}
+	}
+	return nil
+}
+
+// resolveTitle tries each of cfg.TitleSources in order and returns the first
+// non-empty title. headingLine is the index in text of the heading line the
+// title came from, or -1. The file name is the last resort.
+func resolveTitle(cfg models.Config, fm frontmatter, text, path string) (title string, headingLine int) {
+	sources := cfg.TitleSources
+	if len(sources) == 0 {
+		sources = DefaultTitleSources
+	}
+	lines := strings.Split(text, "\n")
+
+	for _, source := range sources {
+		switch source {
+		case TitleFromFrontmatter:
+			title, headingLine = strings.TrimSpace(fm.Title), -1
+		case TitleFromH1:
+			title, headingLine = findHeading(lines, "# ")
+		case TitleFromH2:
+			title, headingLine = findHeading(lines, "## ")
+		case TitleFromDataview:
+			title, headingLine = findDataviewTitle(lines), -1
+		case TitleFromAliases:
+			title, headingLine = firstAlias(fm.Aliases), -1
+		case TitleFromFilename:
+			title, headingLine = titleFromFilename(path), -1
+		}
+		if cfg.StripTitleMarkdown {
+			title = stripInlineMarkdown(title)
+		}
+		if title != "" {
+			return limitTitle(cfg, title, headingLine)
+		}
+	}
+	return limitTitle(cfg, titleFromFilename(path), -1)
+}
+
+var (
+	inlineImageRegex  = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	inlineCodeRegex   = regexp.MustCompile("`([^`]*)`")
+	inlineBoldRegex   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	inlineItalicRegex = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*|(^|[^\pL\pN_])_([^_\s](?:[^_]*[^_\s])?)_([^\pL\pN_]|$)`)
+	inlineStrikeRegex = regexp.MustCompile(`~~(.+?)~~`)
+)
+
+// stripInlineMarkdown removes inline markdown from s: emphasis, strikethrough
+// and code markers, and link, image and [[wikilink]] syntax (keeping their
+// text). Underscores inside words, as in snake_case, are left alone.
+func stripInlineMarkdown(s string) string {
+	s = inlineImageRegex.ReplaceAllString(s, "$1")
+	s = excerptLinkRegex.ReplaceAllString(s, "$1")
+	s = excerptWikilinkRegex.ReplaceAllString(s, "$1")
+	s = inlineCodeRegex.ReplaceAllString(s, "$1")
+	s = inlineBoldRegex.ReplaceAllString(s, "$1$2")
+	s = inlineItalicRegex.ReplaceAllString(s, "$1$2$3$4")
+	s = inlineStrikeRegex.ReplaceAllString(s, "$1")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// limitTitle truncates title to cfg.TitleMaxLength characters, ellipsis
+// included. A truncated heading isn't reported, so StripTitleHeading keeps the
+// full text in the body.
+func limitTitle(cfg models.Config, title string, headingLine int) (string, int) {
+	if cfg.TitleMaxLength <= 0 || utf8.RuneCountInString(title) <= cfg.TitleMaxLength {
+		return title, headingLine
+	}
+	return truncateWords(title, cfg.TitleMaxLength-1), -1
+}
+
+// findHeading returns the text and line index of the first line starting with prefix.
+func findHeading(lines []string, prefix string) (string, int) {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) {
+			if title := strings.TrimSpace(strings.TrimPrefix(line, prefix)); title != "" {
+				return title, i
+			}
+		}
+	}
+	return "", -1
+}
+
+// findDataviewTitle returns the value of the first "title:: ..." line.
+func findDataviewTitle(lines []string) string {
+	for _, line := range lines {
+		if m := dataviewTitleRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
+
+// firstAlias returns the first non-blank frontmatter alias.
+func firstAlias(aliases []string) string {
+	for _, a := range aliases {
+		if a = strings.TrimSpace(a); a != "" {
+			return a
+		}
+	}
+	return ""
+}
+
+func titleFromFilename(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import "testing"
+
+func TestResolveTitleChain(t *testing.T) {
+	const (
+		withAll     = "---\ntitle: From Frontmatter\naliases: [First Alias, Second]\n---\n# From H1\n\n## From H2\ntitle:: From Dataview\n"
+		aliasesOnly = "---\naliases:\n  - First Alias\n  - Second\n---\nJust text, no heading.\n"
+		blankAlias  = "---\naliases: [\" \", Real Alias]\n---\nText.\n"
+		noMetadata  = "Plain text.\n\n## From H2\ntitle:: From Dataview\n"
+	)
+	tests := []struct {
+		name, content string
+		sources       []string
+		want          string
+	}{
+		{"frontmatter wins by default", withAll, nil, "From Frontmatter"},
+		{"aliases without title or heading", aliasesOnly, nil, "First Alias"},
+		{"blank aliases are skipped", blankAlias, nil, "Real Alias"},
+		{"filename last", noMetadata, nil, "my-note"},
+		{"h1 first", withAll, []string{"h1", "frontmatter"}, "From H1"},
+		{"h2", withAll, []string{"h2"}, "From H2"},
+		{"dataview", noMetadata, []string{"dataview", "h2"}, "From Dataview"},
+		{"aliases before h1", withAll, []string{"aliases", "h1"}, "First Alias"},
+		{"missing sources fall through", noMetadata, []string{"frontmatter", "h1", "aliases", "h2"}, "From H2"},
+		{"filename when every source is empty", noMetadata, []string{"frontmatter", "h1"}, "my-note"},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.Frontmatter = FrontmatterParse
+		if tt.sources != nil {
+			cfg.TitleSources = tt.sources
+		}
+		if n := parseTestNote(t, cfg, "my-note.md", tt.content); n.Title != tt.want {
+			t.Errorf("%s: title %q; want %q", tt.name, n.Title, tt.want)
+		}
+	}
+}
+
+func TestTitleMaxLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		heading string
+		max     int
+		want    string
+	}{
+		{"already short", "Short title", 40, "Short title"},
+		{"exactly the limit", "Exactly twenty chars", 20, "Exactly twenty chars"},
+		{"word boundary", "The quick brown fox jumps over the lazy dog", 20, "The quick brown fox…"},
+		{"trailing punctuation dropped", "Meeting notes, Q1 planning session", 16, "Meeting notes…"},
+		{"one long word is cut", "Supercalifragilisticexpialidocious", 10, "Supercali…"},
+		{"counts characters, not bytes", "日本語のとても長いタイトルです", 6, "日本語のと…"},
+		{"0 means unlimited", "The quick brown fox jumps over the lazy dog", 0, "The quick brown fox jumps over the lazy dog"},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.TitleMaxLength = tt.max
+		cfg.StripTitleHeading = true
+		content := "# " + tt.heading + "\n\nBody.\n"
+		n := parseTestNote(t, cfg, "note.md", content)
+		if n.Title != tt.want {
+			t.Errorf("%s: title %q; want %q", tt.name, n.Title, tt.want)
+		}
+		// A truncated heading stays in the body; a whole one is stripped as usual
+		wantBody := "Body.\n"
+		if tt.want != tt.heading {
+			wantBody = content
+		}
+		if n.Body != wantBody {
+			t.Errorf("%s: body %q; want %q", tt.name, n.Body, wantBody)
+		}
+	}
+}
+
+func TestStripInlineMarkdown(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"**Bold** title", "Bold title"},
+		{"__Bold__ title", "Bold title"},
+		{"An *italic* word", "An italic word"},
+		{"An _italic_ word", "An italic word"},
+		{"***Both***", "Both"},
+		{"Run `go test` now", "Run go test now"},
+		{"~~Old~~ New", "Old New"},
+		{"See [the docs](https://example.com/docs)", "See the docs"},
+		{"Link to [[Other Note]]", "Link to Other Note"},
+		{"![logo](img/logo.png) Project", "logo Project"},
+		{"**[Bold link](x.md)** and `code`", "Bold link and code"},
+		{"snake_case_name stays", "snake_case_name stays"},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+		{"  spaced   out  ", "spaced out"},
+	}
+	for _, tt := range tests {
+		if got := stripInlineMarkdown(tt.in); got != tt.want {
+			t.Errorf("stripInlineMarkdown(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripTitleMarkdown(t *testing.T) {
+	cfg := testConfig()
+	cfg.StripTitleMarkdown = true
+	n := parseTestNote(t, cfg, "note.md", "# **Q3** plan for `api` and [[Billing]]\n\nBody.\n")
+	if want := "Q3 plan for api and Billing"; n.Title != want {
+		t.Errorf("title %q; want %q", n.Title, want)
+	}
+
+	cfg.StripTitleMarkdown = false
+	n = parseTestNote(t, cfg, "note.md", "# **Q3** plan\n")
+	if want := "**Q3** plan"; n.Title != want {
+		t.Errorf("unstripped title %q; want %q", n.Title, want)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// Supported values for Config.UIDFormat
+const (
+	UIDShort  = "short"  // 15 lowercase alphanumerics
+	UIDUUID   = "uuid"   // RFC 4122 version 4
+	UIDNanoID = "nanoid" // 21 URL-safe characters
+)
+
+const (
+	shortCharset  = "0123456789abcdefghijklmnopqrstuvwxyz"
+	nanoIDCharset = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// ValidateUIDFormat rejects unsupported --uid-format values.
+func ValidateUIDFormat(format string) error {
+	switch format {
+	case UIDShort, UIDUUID, UIDNanoID:
+		return nil
+	}
+	return fmt.Errorf("unsupported uid format %q (want %s, %s or %s)", format, UIDShort, UIDUUID, UIDNanoID)
+}
+
+// uidAttempts bounds how often GenerateID regenerates after a collision.
+const uidAttempts = 5
+
+// issuedIDs holds every uid handed out by this process, so a run never
+// reuses one even if the random source repeats itself.
+var issuedIDs = struct {
+	sync.Mutex
+	seen map[string]struct{}
+}{seen: make(map[string]struct{})}
+
+// GenerateID returns a new uid in cfg.UIDFormat, defaulting to short, that
+// hasn't been returned before in this process.
+func GenerateID(cfg models.Config) (string, error) {
+	issuedIDs.Lock()
+	defer issuedIDs.Unlock()
+
+	for attempt := 0; attempt < uidAttempts; attempt++ {
+		id, err := newID(cfg.UIDFormat)
+		if err != nil {
+			return "", fmt.Errorf("generate uid: %w", err)
+		}
+		if _, dup := issuedIDs.seen[id]; !dup {
+			issuedIDs.seen[id] = struct{}{}
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("generate uid: %d attempts all collided with earlier uids", uidAttempts)
+}
+
+func newID(format string) (string, error) {
+	switch format {
+	case UIDUUID:
+		return uuidV4()
+	case UIDNanoID:
+		return randomString(nanoIDCharset, 21)
+	}
+	return randomString(shortCharset, 15)
+}
+
+func randomString(charset string, length int) (string, error) {
+	result := make([]byte, length)
+	max := big.NewInt(int64(len(charset)))
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = charset[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// uuidV4 formats 16 random bytes as an RFC 4122 version 4 UUID.
+func uuidV4() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -22,81 +22,581 @@ THE SOFTWARE.
 package utils
 
 import (
-	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
-	"math/big"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/sottey/tududimport/internal/models"
+	"golang.org/x/text/unicode/norm"
 )
 
-var tagRegex = regexp.MustCompile(`#([A-Za-z0-9_\-]+)`)
+// headingRegex matches an ATX heading line such as "# Title" or "### Notes".
+var headingRegex = regexp.MustCompile(`^#{1,6}(\s|$)`)
 
-// discoverNotes walks the root dir and returns Note structs for each .md file.
-func DiscoverNotes(cfg models.Config) ([]models.Note, error) {
+// tagRegex matches #tags, including nested ones like #work/client-a. As in
+// Obsidian, any Unicode letter, mark or digit counts, so #café and #日本語 match whole.
+var tagRegex = regexp.MustCompile(`#([\p{L}\p{M}\p{N}_\-]+(?:/[\p{L}\p{M}\p{N}_\-]+)*)`)
+
+// wikilinkRegex matches [[Target]], [[Target|Alias]] and ![[embeds]]
+var wikilinkRegex = regexp.MustCompile(`(!?)\[\[([^\[\]]+)\]\]`)
+
+// RootsOf returns the roots to import from: cfg.Roots, or just cfg.Root when
+// Roots is empty.
+func RootsOf(cfg models.Config) []string {
+	if len(cfg.Roots) > 0 {
+		return cfg.Roots
+	}
+	return []string{cfg.Root}
+}
+
+// candidate is a markdown file found during the walk, waiting to be parsed.
+type candidate struct {
+	path string
+	info os.FileInfo
+}
+
+// discoverNotes walks each root and returns Note structs for each markdown
+// file, sorted by path within each root, along with counts of what discovery dropped or changed.
+// Files are parsed concurrently by cfg.Workers workers; bodies are rendered
+// with cfg.Renderer, if any, once parsing and dedupe are done.
+func DiscoverNotes(cfg models.Config) ([]models.Note, models.DiscoveryStats, error) {
+	var stats models.DiscoveryStats
+
+	if cfg.TagMapFile != "" && cfg.TagMap == nil {
+		tagMap, err := LoadTagMap(cfg.TagMapFile)
+		if err != nil {
+			return nil, stats, fmt.Errorf("load tag map: %w", err)
+		}
+		cfg.TagMap = tagMap
+	}
+	if len(cfg.TagTransforms) > 0 && cfg.TagChain == nil {
+		chain, err := BuildTagTransforms(cfg.TagTransforms)
+		if err != nil {
+			return nil, stats, err
+		}
+		cfg.TagChain = chain
+	}
+	if cfg.BodyTemplate != "" && cfg.BodyTmpl == nil {
+		tmpl, err := ParseBodyTemplate(cfg.BodyTemplate)
+		if err != nil {
+			return nil, stats, err
+		}
+		cfg.BodyTmpl = tmpl
+	}
+	if cfg.RenderHTML && cfg.Renderer == nil {
+		renderer, err := NewHTMLRenderer(cfg.HTMLExtensions)
+		if err != nil {
+			return nil, stats, err
+		}
+		cfg.Renderer = renderer
+	}
+
+	// Each root is walked with itself as cfg.Root, so folder tags and RelPath
+	// are relative to it. A file reachable from two roots is kept once.
+	roots := RootsOf(cfg)
+	var listed map[string][]string
+	if cfg.FilesFrom != "" {
+		files, err := LoadFileList(cfg.FilesFrom)
+		if err != nil {
+			return nil, stats, fmt.Errorf("read file list: %w", err)
+		}
+		var outside []string
+		if listed, outside, err = assignFiles(roots, files); err != nil {
+			return nil, stats, err
+		}
+		for _, f := range outside {
+			stats.FileErrors = append(stats.FileErrors, fmt.Sprintf("%s: not under any root", f))
+			stats.Warnings = append(stats.Warnings, models.Warning{Path: f, Msg: fmt.Sprintf("skipping %s: not under any root", f)})
+		}
+	}
 	var notes []models.Note
+	seen := make(map[string]bool)
+	remaining := cfg.DryRunLimit
+	for _, root := range roots {
+		if cfg.DryRunLimit > 0 && remaining <= 0 {
+			break
+		}
+		rootCfg := cfg
+		rootCfg.Root = root
+		rootCfg.DryRunLimit = remaining
+		if listed != nil {
+			rootCfg.Files = listed[root]
+		}
+		if cfg.DatesFromGit && cfg.GitDates == nil && !isArchive(root) {
+			// In single-file mode collectNotes makes the file's directory the root
+			dir := root
+			if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+				dir = filepath.Dir(dir)
+			}
+			if InGitWorkTree(dir) {
+				gitDates, err := LoadGitDates(dir)
+				if err != nil {
+					return nil, stats, fmt.Errorf("load git dates: %w", err)
+				}
+				rootCfg.GitDates = gitDates
+			} else {
+				msg := fmt.Sprintf("%s is not in a git repository; using file times instead of --dates-from-git", root)
+				stats.Warnings = append(stats.Warnings, models.Warning{Path: root, Msg: msg})
+			}
+		}
 
-	err := filepath.Walk(cfg.Root, func(path string, info os.FileInfo, err error) error {
+		files := stats.Files
+		found, err := collectNotes(rootCfg, &stats)
 		if err != nil {
-			return err
+			return nil, stats, err
+		}
+		count := 0
+		for _, n := range found {
+			abs, err := filepath.Abs(n.Path)
+			if err != nil {
+				abs = n.Path
+			}
+			if seen[abs] {
+				stats.Warnings = append(stats.Warnings, models.Warning{Path: n.Path, Msg: fmt.Sprintf("skipping %s: already found under another root", n.Path)})
+				continue
+			}
+			seen[abs] = true
+			notes = append(notes, n)
+			count++
+		}
+		// The limit counts files picked up, including any that failed to parse
+		remaining -= stats.Files - files
+		if len(roots) > 1 {
+			stats.Roots = append(stats.Roots, models.RootCount{Root: root, Notes: count})
+		}
+	}
+	if stats.Limited {
+		stats.Warnings = append(stats.Warnings, models.Warning{Msg: fmt.Sprintf("stopped after %d files (--dry-run-limit); results are not exhaustive", cfg.DryRunLimit)})
+	}
+	if cfg.FailOnError && len(stats.FileErrors) > 0 {
+		return nil, stats, fmt.Errorf("%d files could not be read:\n  %s", len(stats.FileErrors), strings.Join(stats.FileErrors, "\n  "))
+	}
+	if cfg.Preview > 0 && len(notes) > cfg.Preview {
+		notes = notes[:cfg.Preview]
+	}
+
+	if cfg.SkipEmpty {
+		kept := notes[:0]
+		for _, n := range notes {
+			if isEmptyNote(cfg, n) {
+				stats.Empty++
+				stats.Warnings = append(stats.Warnings, models.Warning{Path: n.Path, Msg: fmt.Sprintf("skipping %s: empty note", n.Path)})
+				continue
+			}
+			kept = append(kept, n)
+		}
+		notes = kept
+	}
+	tagWarn := func(path, msg string) { stats.Warnings = append(stats.Warnings, models.Warning{Path: path, Msg: msg}) }
+	dropShortTags(cfg, notes, tagWarn)
+	limitTagLength(cfg, notes, tagWarn)
+	if cfg.OnlyTagged {
+		kept := notes[:0]
+		for _, n := range notes {
+			if len(n.Tags) == 0 {
+				stats.Untagged++
+				stats.Warnings = append(stats.Warnings, models.Warning{Path: n.Path, Msg: fmt.Sprintf("skipping %s: no tags", n.Path)})
+				continue
+			}
+			kept = append(kept, n)
+		}
+		notes = kept
+	}
+
+	notes, stats.Deduped = dedupeNotes(cfg, notes)
+	if err := renderNotes(cfg, notes); err != nil {
+		return nil, stats, err
+	}
+	return notes, stats, nil
+}
+
+// collectNotes parses the notes under cfg.Root, which may be a directory, a
+// single .md file or an archive, sorted by path. With cfg.FilesFrom only
+// cfg.Files are considered instead of walking the directory.
+func collectNotes(cfg models.Config, stats *models.DiscoveryStats) ([]models.Note, error) {
+	warn := func(path, msg string) { stats.Warnings = append(stats.Warnings, models.Warning{Path: path, Msg: msg}) }
+	fileErr := func(path string, err error) {
+		if errors.Is(err, errDraft) {
+			stats.Drafts++
+			warn(path, fmt.Sprintf("skipping %s: draft", path))
+			return
+		}
+		reason := err
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			reason = pathErr.Err
+		}
+		stats.FileErrors = append(stats.FileErrors, fmt.Sprintf("%s: %v", path, reason))
+		warn(path, fmt.Sprintf("skipping %s: %v", path, reason))
+	}
+	keep := func(path string, size int64, modTime time.Time) bool {
+		if !inDateWindow(cfg, modTime) {
+			stats.DateFiltered++
+			return false
+		}
+		return keepSized(cfg, path, size, warn)
+	}
+
+	rootInfo, err := os.Stat(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	if !rootInfo.IsDir() && isArchive(cfg.Root) {
+		return discoverArchive(cfg, keep, fileErr, stats)
+	}
+	if !rootInfo.IsDir() {
+		// Single-file mode: the file's directory acts as root, so it gets no folder tags
+		if !rootInfo.Mode().IsRegular() || !isMarkdown(cfg.Extensions, rootInfo.Name()) {
+			return nil, fmt.Errorf("root %s is not a directory, markdown file, or .zip/.tar.gz archive", cfg.Root)
+		}
+		path := cfg.Root
+		cfg.Root = filepath.Dir(path)
+		if !keep(path, rootInfo.Size(), rootInfo.ModTime()) {
+			return nil, nil
+		}
+		stats.Files++
+		return parseCandidates(cfg, []candidate{{path: path, info: rootInfo}}, fileErr), nil
+	}
+	if cfg.FilesFrom != "" {
+		candidates := listedCandidates(cfg, keep, fileErr)
+		if cfg.DryRunLimit > 0 && len(candidates) > cfg.DryRunLimit {
+			candidates = candidates[:cfg.DryRunLimit]
+			stats.Limited = true
+		}
+		if cfg.Preview > 0 && len(candidates) > cfg.Preview {
+			candidates = candidates[:cfg.Preview]
+		}
+		stats.Files += len(candidates)
+		return parseCandidates(cfg, candidates, fileErr), nil
+	}
+
+	var (
+		candidates []candidate
+		ignore     gitignore
+	)
+
+	err = walkTree(cfg.Root, cfg.FollowSymlinks, warn, func(path string, info os.FileInfo, err error) error {
+		// Only an unreadable root is fatal; anything below it is skipped and reported
+		if err != nil {
+			if path == cfg.Root {
+				return err
+			}
+			fileErr(path, err)
+			return nil
+		}
+		rel, err := filepath.Rel(cfg.Root, path)
+		if err != nil {
+			rel = path
+		}
+		// Excludes (and .gitignore) win over includes
+		if rel != "." {
+			skip := matchesAny(cfg.Exclude, rel)
+			if cfg.RespectGitignore {
+				skip = skip || info.Name() == ".git" || ignore.ignored(rel, info.IsDir())
+			}
+			if skip {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 		}
 		if info.IsDir() {
+			if cfg.RespectGitignore {
+				if err := ignore.load(cfg.Root, rel); err != nil {
+					return fmt.Errorf("read .gitignore in %s: %w", path, err)
+				}
+			}
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(info.Name()), ".md") {
+		if !isMarkdown(cfg.Extensions, info.Name()) {
+			return nil
+		}
+		if len(cfg.Include) > 0 && !matchesAny(cfg.Include, rel) {
+			return nil
+		}
+		if !keep(path, info.Size(), info.ModTime()) {
 			return nil
 		}
 
-		n, err := parseMarkdownNote(cfg, path, info)
-		if err != nil {
-			return fmt.Errorf("parse %s: %w", path, err)
+		candidates = append(candidates, candidate{path: path, info: info})
+		if cfg.DryRunLimit > 0 && len(candidates) >= cfg.DryRunLimit {
+			stats.Limited = true
+			return filepath.SkipAll
 		}
-		notes = append(notes, n)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].path < candidates[j].path })
+	if cfg.Preview > 0 && len(candidates) > cfg.Preview {
+		candidates = candidates[:cfg.Preview]
+	}
+	stats.Files += len(candidates)
+	return parseCandidates(cfg, candidates, fileErr), nil
+}
+
+// parseCandidates parses files with a bounded worker pool. Results keep the
+// order of candidates; files that fail to read or parse are left out and
+// passed to fileErr, in order, once every file is done.
+func parseCandidates(cfg models.Config, candidates []candidate, fileErr func(string, error)) []models.Note {
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	notes := make([]models.Note, len(candidates))
+	errs := make([]error, len(candidates))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := candidates[i]
+				notes[i], errs[i] = parseMarkdownNote(cfg, c.path, c.info)
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	parsed := notes[:0]
+	for i, n := range notes {
+		if errs[i] != nil {
+			fileErr(candidates[i].path, errs[i])
+			continue
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed
+}
+
+// isEmptyNote reports whether n's body (frontmatter already removed) is blank,
+// or with cfg.SkipTitleOnly holds nothing but a single heading line.
+func isEmptyNote(cfg models.Config, n models.Note) bool {
+	var lines []string
+	for _, line := range strings.Split(n.Body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	switch {
+	case len(lines) == 0:
+		return true
+	case len(lines) == 1 && cfg.SkipTitleOnly:
+		return headingRegex.MatchString(lines[0])
+	}
+	return false
+}
+
+// keepFunc decides, before a file is read, whether it should be parsed.
+type keepFunc func(path string, size int64, modTime time.Time) bool
+
+// inDateWindow reports whether modTime falls within cfg.Since and cfg.Until;
+// a zero bound is open.
+func inDateWindow(cfg models.Config, modTime time.Time) bool {
+	if !cfg.Since.IsZero() && modTime.Before(cfg.Since) {
+		return false
+	}
+	return cfg.Until.IsZero() || !modTime.After(cfg.Until)
+}
 
-	return notes, err
+// normalizeText strips a leading UTF-8 BOM and converts CRLF/CR line endings to LF.
+func normalizeText(s string) string {
+	s = strings.TrimPrefix(s, "\ufeff")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
 }
 
+// DefaultExtensions are the markdown file extensions used when --extensions isn't set.
+var DefaultExtensions = []string{"md"}
+
+// isMarkdown reports whether name has one of exts (without the leading dot,
+// compared case-insensitively), or DefaultExtensions when exts is empty.
+func isMarkdown(exts []string, name string) bool {
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(e), "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether rel (or its basename) matches any of the glob patterns.
+func matchesAny(patterns []string, rel string) bool {
+	base := filepath.Base(rel)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// errDraft is returned by parseNote for a note SkipDrafts leaves out.
+var errDraft = errors.New("draft")
+
 // parseMarkdownNote reads a .md file, extracts title, body, tags, and file timestamps.
 func parseMarkdownNote(cfg models.Config, path string, info os.FileInfo) (models.Note, error) {
-	data, err := os.ReadFile(path)
+	// Files over MaxBodySize only get here in truncate mode
+	data, err := readFileLimited(path, cfg.MaxBodySize)
 	if err != nil {
 		return models.Note{}, err
 	}
-	text := string(data)
-	lines := strings.Split(text, "\n")
+	created, modified := fileTimes(info, path)
+	if cfg.GitDates != nil {
+		if rel, err := filepath.Rel(cfg.Root, path); err == nil {
+			if d, ok := cfg.GitDates[filepath.ToSlash(rel)]; ok {
+				created, modified = d.Created, d.Updated
+			}
+		}
+	}
+	return parseNote(cfg, path, data, created, modified)
+}
 
-	title := ""
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "# ") {
-			title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
-			break
+// parseNote extracts title, body, tags and timestamps from markdown content.
+// path is used for the filename title and folder tags relative to cfg.Root;
+// created/modified are the source's own timestamps.
+func parseNote(cfg models.Config, path string, data []byte, created, modified time.Time) (models.Note, error) {
+	var err error
+	text := normalizeText(string(data))
+
+	// Leading YAML frontmatter is kept out of the stored body (unless off) and
+	// only interpreted in parse mode. SkipDrafts reads its draft flags in any mode.
+	var (
+		fm       frontmatter
+		metadata string
+	)
+	if cfg.Frontmatter != FrontmatterOff || cfg.SkipDrafts {
+		if block, body, ok := splitFrontmatter(text); ok {
+			if cfg.Frontmatter == FrontmatterParse {
+				fm, err = parseFrontmatter(block)
+				if err != nil {
+					return models.Note{}, fmt.Errorf("frontmatter: %w", err)
+				}
+				if cfg.SkipDrafts && fm.isDraft() {
+					return models.Note{}, errDraft
+				}
+				if cfg.FrontmatterMeta {
+					if metadata, err = frontmatterMetadata(block); err != nil {
+						return models.Note{}, fmt.Errorf("frontmatter metadata: %w", err)
+					}
+				}
+			} else if cfg.SkipDrafts {
+				// A block that doesn't parse has no draft flags to honour
+				if flags, err := parseFrontmatter(block); err == nil && flags.isDraft() {
+					return models.Note{}, errDraft
+				}
+			}
+			if cfg.Frontmatter != FrontmatterOff {
+				text = body
+			}
 		}
 	}
-	if title == "" {
-		base := filepath.Base(path)
-		title = strings.TrimSuffix(base, filepath.Ext(base))
+
+	// Logseq page properties act like frontmatter
+	if cfg.Format == FormatLogseq {
+		props, rest := splitLogseqProperties(text)
+		applyLogseqProperties(&fm, props)
+		if cfg.StripProperties {
+			text = rest
+		}
 	}
 
+	// MultiMarkdown metadata too, and is always removed from the body
+	if cfg.Format == FormatMMD {
+		if props, rest, ok := splitMMDMetadata(text); ok {
+			applyMMDMetadata(&fm, props)
+			text = rest
+		}
+	}
+
+	title, headingLine := resolveTitle(cfg, fm, text, path)
+
 	var tags []string
 
+	// Frontmatter tags
+	if cfg.TagFromFrontmatter {
+		for _, t := range fm.Tags.List {
+			if slug := tagSlug(strings.TrimPrefix(strings.TrimSpace(t), "#")); slug != "" {
+				tags = append(tags, slug)
+			}
+		}
+		for _, t := range splitTagString(fm.Tags.Scalar, cfg.TagSeparators) {
+			if slug := slugify(t); slug != "" {
+				tags = append(tags, slug)
+			}
+		}
+	}
+
 	// Inline #tags
 	if cfg.TagFromHashtags {
 		matches := tagRegex.FindAllStringSubmatch(text, -1)
 		for _, m := range matches {
 			if len(m) > 1 {
-				tags = append(tags, m[1])
+				tag := tagSlug(m[1])
+				if tag == "" {
+					continue
+				}
+				found := []string{tag}
+				if cfg.ExpandNestedTags {
+					found = expandNestedTag(tag)
+				}
+				for _, t := range found {
+					tags = append(tags, prefixTag(cfg.HashtagPrefix, cfg.TagPrefixSeparator, t))
+				}
 			}
 		}
 	}
 
+	// [[Wikilink]] targets
+	if cfg.WikilinksAsTags {
+		for _, target := range extractWikilinks(text) {
+			slug := slugify(target)
+			if slug != "" {
+				tags = append(tags, slug)
+			}
+		}
+	}
+
+	var tasks []models.Task
+	if cfg.ImportTasks {
+		tasks = extractTasks(text)
+	}
+
+	var links []string
+	if cfg.ImportNoteLinks {
+		links = UniqueStrings(extractWikilinks(text))
+	}
+
 	// Folder-based tags: *all* folders under root, e.g. cottage/foo/bar/file.md => cottage, foo, bar
 	if cfg.TagFromFolders {
 		rel, err := filepath.Rel(cfg.Root, path)
@@ -104,121 +604,403 @@ func parseMarkdownNote(cfg models.Config, path string, info os.FileInfo) (models
 			dirPart := filepath.Dir(rel)
 			if dirPart != "." {
 				parts := strings.Split(dirPart, string(os.PathSeparator))
+				if cfg.FolderTagDepth > 0 && len(parts) > cfg.FolderTagDepth {
+					parts = parts[:cfg.FolderTagDepth]
+				}
+				var slugs []string
 				for _, p := range parts {
 					slug := slugify(p)
+					if mapped, ok := cfg.TagMap[slug]; ok {
+						slug = mapped
+					}
 					if slug != "" {
-						tags = append(tags, slug)
+						slugs = append(slugs, slug)
 					}
 				}
+				// cottage/foo/bar => cottage-foo-bar
+				if cfg.FlattenFolderTags && len(slugs) > 0 {
+					slugs = []string{strings.Join(slugs, cfg.FlattenSeparator)}
+				}
+				for _, slug := range slugs {
+					tags = append(tags, prefixTag(cfg.FolderTagPrefix, cfg.TagPrefixSeparator, slug))
+				}
 			}
 		}
 	}
 
-	// File timestamps (using ModTime for both created/updated)
-	modTime := info.ModTime()
+	tags = filterTags(cfg, applyTagTransforms(cfg.TagChain, tags))
 
-	return models.Note{
+	// --add-tag values apply to every note and bypass the allow/deny lists
+	for _, t := range cfg.ExtraTags {
+		if slug := slugify(t); slug != "" {
+			tags = append(tags, slug)
+		}
+	}
+	// Every source is slugified, so #Work and a work/ folder are one tag here
+	tags = UniqueStrings(tags)
+
+	body := text
+	if cfg.StripTitleHeading && headingLine >= 0 {
+		body = removeHeadingLine(strings.Split(text, "\n"), headingLine)
+	}
+	if cfg.CollapseBlankLines {
+		body = collapseBlankLines(body)
+	}
+
+	// Source timestamps, overridden by a filename date, then by --created-at
+	// and --updated-at, and last by frontmatter dates
+	createdAt, updatedAt := created, modified
+	if cfg.DateFromFilename {
+		if d, ok := dateFromFilename(filepath.Base(path), cfg.DatePattern); ok {
+			createdAt, updatedAt = d, d
+		}
+	}
+	if !cfg.FixedCreated.IsZero() {
+		createdAt = cfg.FixedCreated
+	}
+	if !cfg.FixedUpdated.IsZero() {
+		updatedAt = cfg.FixedUpdated
+	}
+	if !fm.Created.IsZero() {
+		createdAt = fm.Created.Time
+	}
+	if !fm.Updated.IsZero() {
+		updatedAt = fm.Updated.Time
+	}
+
+	relPath, err := filepath.Rel(cfg.Root, path)
+	if err != nil {
+		relPath = path
+	}
+
+	if cfg.AttachmentsBase != "" {
+		body = rewriteAttachmentLinks(body, relPath, cfg.AttachmentsBase, cfg.Extensions)
+	}
+
+	note := models.Note{
 		Title:     title,
-		Body:      text,
+		Body:      body,
 		Tags:      tags,
+		Links:     links,
+		Tasks:     tasks,
+		Metadata:  metadata,
 		Path:      path,
-		CreatedAt: modTime,
-		UpdatedAt: modTime,
-	}, nil
+		RelPath:   relPath,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		// Hashed before the template and rendering, which can change from run to run
+		ContentHash: ContentHash(body),
+	}
+	if cfg.BodyTmpl != nil {
+		if note.Body, err = applyBodyTemplate(cfg, note, body); err != nil {
+			return models.Note{}, err
+		}
+	}
+	return note, nil
+}
+
+// prefixTag namespaces tag as prefix+sep+tag; an empty prefix leaves it alone.
+func prefixTag(prefix, sep, tag string) string {
+	if prefix == "" {
+		return tag
+	}
+	return prefix + sep + tag
+}
+
+// expandNestedTag turns "work/client-a/x" into "work", "work/client-a", "work/client-a/x".
+func expandNestedTag(tag string) []string {
+	parts := strings.Split(tag, "/")
+	out := make([]string, 0, len(parts))
+	for i := range parts {
+		out = append(out, strings.Join(parts[:i+1], "/"))
+	}
+	return out
+}
+
+// removeHeadingLine drops lines[i] and a single blank line right after it.
+func removeHeadingLine(lines []string, i int) string {
+	end := i + 1
+	if end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+		end++
+	}
+	out := append(append([]string{}, lines[:i]...), lines[end:]...)
+	return strings.Join(out, "\n")
+}
+
+// collapseBlankLines squashes runs of three or more blank lines into a single
+// blank line, leaving fenced code blocks (``` or ~~~) untouched.
+func collapseBlankLines(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	blanks := 0
+	flush := func() {
+		if blanks >= 3 {
+			blanks = 1
+		}
+		for ; blanks > 0; blanks-- {
+			out = append(out, "")
+		}
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" && trimmed == "" {
+			blanks++
+			continue
+		}
+		flush()
+		if marker := fenceMarker(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(marker, fence) && strings.TrimLeft(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+		}
+		out = append(out, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// fenceMarker returns the run of backticks or tildes opening a code fence
+// line, or "" when line isn't one.
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// filterTags applies the --tag-allow and --tag-deny glob lists. When an allow
+// list is set only matching tags are kept, and the deny list filters within that.
+func filterTags(cfg models.Config, tags []string) []string {
+	if len(cfg.TagAllow) == 0 && len(cfg.TagDeny) == 0 {
+		return tags
+	}
+	var out []string
+	for _, t := range tags {
+		if len(cfg.TagAllow) > 0 && !matchesTag(cfg.TagAllow, t) {
+			continue
+		}
+		if matchesTag(cfg.TagDeny, t) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// matchesTag reports whether tag matches any of the (case-insensitive) glob patterns.
+func matchesTag(patterns []string, tag string) bool {
+	tag = strings.ToLower(tag)
+	for _, p := range patterns {
+		if ok, _ := pathpkg.Match(strings.ToLower(p), tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extractWikilinks returns the link targets of [[...]] links in text. For
+// [[Note|Alias]] and [[Note#Heading]] the target is "Note"; ![[embeds]] are ignored.
+func extractWikilinks(text string) []string {
+	var targets []string
+	for _, m := range wikilinkRegex.FindAllStringSubmatch(text, -1) {
+		if m[1] == "!" {
+			continue
+		}
+		target := m[2]
+		if i := strings.Index(target, "|"); i >= 0 {
+			target = target[:i]
+		}
+		if i := strings.Index(target, "#"); i >= 0 {
+			target = target[:i]
+		}
+		target = strings.TrimSpace(target)
+		if target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// dateFromFilename parses a date at the start of a file name such as
+// "2024-03-15 Meeting.md" using layout (e.g. "2006-01-02"). An empty layout
+// tries the formats parseFlexibleDate knows. Either way the date is in UTC.
+func dateFromFilename(name, layout string) (time.Time, bool) {
+	if layout == "" {
+		return flexibleDateFromFilename(name)
+	}
+	if len(name) < len(layout) {
+		return time.Time{}, false
+	}
+	d, err := time.Parse(layout, name[:len(layout)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
 }
 
 // insertNote inserts into the notes table and returns the inserted note ID.
 func InsertNote(tx *sql.Tx, cfg models.Config, n models.Note) (int64, error) {
 	createdStr := n.CreatedAt.UTC().Format("2006-01-02 15:04:05.000 +00:00")
 	updatedStr := n.UpdatedAt.UTC().Format("2006-01-02 15:04:05.000 +00:00")
-	uid := GenerateID() // uuid.New().String()
+	uid, err := GenerateID(cfg)
+	if err != nil {
+		return 0, err
+	}
 
-	var (
-		sqlStr string
-		args   []interface{}
-	)
+	cols := []string{"uid", "title", quoteIdent(notesContentCol(cfg)), "user_id"}
+	args := []interface{}{uid, n.Title, n.Body, cfg.UserID}
 
 	if cfg.ProjectID >= 0 {
-		// with project_id
-		sqlStr = `
-			INSERT INTO notes (uid, title, content, user_id, project_id, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-		`
-		args = []interface{}{uid, n.Title, n.Body, cfg.UserID, cfg.ProjectID, createdStr, updatedStr}
-	} else {
-		// without project_id
-		sqlStr = `
-			INSERT INTO notes (uid, title, content, user_id, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?)
+		cols = append(cols, "project_id")
+		args = append(args, cfg.ProjectID)
+	}
+	if cfg.RecordSource {
+		cols = append(cols, "source_path")
+		args = append(args, n.RelPath)
+	}
+	if cfg.Category != "" {
+		cols = append(cols, "category")
+		args = append(args, cfg.Category)
+	}
+	if cfg.Pinned {
+		cols = append(cols, "pinned")
+		args = append(args, true)
+	}
+	if cfg.Archived {
+		cols = append(cols, "archived")
+		args = append(args, true)
+	}
+	if cfg.ContentHash {
+		cols = append(cols, "content_hash")
+		args = append(args, n.ContentHash)
+	}
+	if cfg.Excerpt {
+		cols = append(cols, "excerpt")
+		args = append(args, noteExcerpt(n.Title, n.Body))
+	}
+	if cfg.FrontmatterMeta {
+		cols = append(cols, "metadata")
+		args = append(args, nullIfEmpty(n.Metadata))
+	}
+
+	cols = append(cols, "created_at", "updated_at")
+	args = append(args, createdStr, updatedStr)
+
+	return insertID(tx, cfg, insertSQL("notes", cols), args...)
+}
+
+// nullIfEmpty returns nil for "" so it is stored as NULL, and s otherwise.
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// insertSQL builds "INSERT INTO table (cols...) VALUES (?, ...)".
+func insertSQL(table string, cols []string) string {
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table,
+		strings.Join(cols, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "),
+	)
+}
+
+// NoteExists reports whether the user already has a note with the same title and content,
+// or (when source paths are recorded) one imported from the same source path.
+func NoteExists(tx *sql.Tx, cfg models.Config, n models.Note) (bool, error) {
+	contentCol := quoteIdent(notesContentCol(cfg))
+	selectSQL := `
+		SELECT 1 FROM notes
+		WHERE user_id = ? AND title = ? AND ` + contentCol + ` = ?
+		LIMIT 1
+	`
+	args := []interface{}{cfg.UserID, n.Title, n.Body}
+	if cfg.RecordSource {
+		selectSQL = `
+			SELECT 1 FROM notes
+			WHERE user_id = ? AND ((title = ? AND ` + contentCol + ` = ?) OR source_path = ?)
+			LIMIT 1
 		`
-		args = []interface{}{uid, n.Title, n.Body, cfg.UserID, createdStr, updatedStr}
+		args = append(args, n.RelPath)
 	}
 
-	res, err := tx.Exec(sqlStr, args...)
+	var one int
+	err := RetryBusy(cfg, func() error { return tx.QueryRow(DialectFor(cfg).Rebind(selectSQL), args...).Scan(&one) })
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	return res.LastInsertId()
+	return true, nil
 }
 
 // getOrCreateTag returns an existing tag id or creates a new one if needed.
-func GetOrCreateTag(tx *sql.Tx, cfg models.Config, cache map[string]int64, name string) (int64, error) {
+// created reports whether a new tag row was inserted.
+func GetOrCreateTag(tx *sql.Tx, cfg models.Config, cache *IDCache, name string) (id int64, created bool, err error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return 0, fmt.Errorf("empty tag name")
+		return 0, false, fmt.Errorf("empty tag name")
 	}
 
 	cacheKey := fmt.Sprintf("%s|%d", name, cfg.UserID)
-	if id, ok := cache[cacheKey]; ok {
-		return id, nil
-	}
+	return cache.GetOrCreate(cacheKey, func() (int64, bool, error) { return findOrInsertTag(tx, cfg, name) })
+}
 
+// findOrInsertTag looks up the user's tag called name, inserting it if missing.
+func findOrInsertTag(tx *sql.Tx, cfg models.Config, name string) (id int64, created bool, err error) {
 	// Try to find existing tag for this user
-	selectSQL := `
-		SELECT id FROM tags
-		WHERE name = ? AND user_id = ?
+	table, nameCol := quoteIdent(tagsTable(cfg)), quoteIdent(tagsNameCol(cfg))
+	selectSQL := fmt.Sprintf(`
+		SELECT id FROM %s
+		WHERE %s = ? AND user_id = ?
 		LIMIT 1
-	`
+	`, table, nameCol)
+	d := DialectFor(cfg)
 	var existingID int64
-	err := tx.QueryRow(selectSQL, name, cfg.UserID).Scan(&existingID)
+	err = RetryBusy(cfg, func() error { return tx.QueryRow(d.Rebind(selectSQL), name, cfg.UserID).Scan(&existingID) })
 	if err == nil {
-		cache[cacheKey] = existingID
-		return existingID, nil
+		return existingID, false, nil
 	}
 	if err != sql.ErrNoRows {
-		return 0, err
+		return 0, false, err
 	}
 
 	// Insert new tag
 	now := time.Now().UTC().Format("2006-01-02 15:04:05.000 +00:00")
-	uid := GenerateID()
-
-	insertSQL := `
-		INSERT INTO tags (uid, name, user_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-	`
-	res, err := tx.Exec(insertSQL, uid, name, cfg.UserID, now, now)
+	uid, err := GenerateID(cfg)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
-	newID, err := res.LastInsertId()
+
+	insertSQL := fmt.Sprintf(`
+		INSERT INTO %s (uid, %s, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, table, nameCol)
+	newID, err := insertID(tx, cfg, insertSQL, uid, name, cfg.UserID, now, now)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
-	cache[cacheKey] = newID
-	return newID, nil
+	return newID, true, nil
 }
 
 // linkNoteTag inserts into the notes_tags intersection table.
 // INSERT OR IGNORE so re-running the importer won't blow up on duplicates.
-func LinkNoteTag(tx *sql.Tx, noteID, tagID int64) error {
+func LinkNoteTag(tx *sql.Tx, cfg models.Config, noteID, tagID int64) error {
 	now := time.Now().UTC().Format("2006-01-02 15:04:05")
-	insertSQL := `
-		INSERT OR IGNORE INTO notes_tags (note_id, tag_id, created_at, updated_at)
+	insertSQL := DialectFor(cfg).InsertOrIgnore(`
+		INSERT INTO notes_tags (note_id, tag_id, created_at, updated_at)
 		VALUES (?, ?, ?, ?)
-	`
-	_, err := tx.Exec(insertSQL, noteID, tagID, now, now)
-	return err
+	`)
+	return execWrite(tx, cfg, insertSQL, noteID, tagID, now, now)
 }
 
 func UniqueStrings(in []string) []string {
@@ -237,33 +1019,54 @@ func UniqueStrings(in []string) []string {
 	return out
 }
 
-// slugify turns "Server Notes" -> "server-notes"
+// tagSlug slugifies each part of a nested tag, keeping the / between them:
+// "Work/Client A" -> "work/client-a".
+func tagSlug(t string) string {
+	var parts []string
+	for _, p := range strings.Split(t, "/") {
+		if slug := slugify(p); slug != "" {
+			parts = append(parts, slug)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// slugReplacer handles Latin letters that don't decompose into base + accent.
+var slugReplacer = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ð", "d", "þ", "th", "ı", "i",
+)
+
+// slugify turns "Server Notes" -> "server-notes" and "Café Notes" -> "cafe-notes".
+// Accents are stripped from Latin letters; other scripts (e.g. "日記") are kept as-is.
 func slugify(s string) string {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return ""
 	}
 	s = strings.ToLower(s)
+	s = slugReplacer.Replace(s)
 	// Replace spaces and some separators with hyphen
 	s = strings.ReplaceAll(s, " ", "-")
 	s = strings.ReplaceAll(s, "_", "-")
-	// Remove characters that aren't letters, numbers, or hyphen
-	var b strings.Builder
-	for _, r := range s {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+	// Decompose so accents become separate combining marks, then keep only
+	// letters, numbers, and hyphens
+	var (
+		b    strings.Builder
+		prev rune
+	)
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			// Accents on Latin letters are dropped; marks in other scripts
+			// (e.g. Japanese dakuten) are part of the letter
+			if !unicode.Is(unicode.Latin, prev) {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		prev = r
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
 			b.WriteRune(r)
 		}
 	}
-	return b.String()
-}
-
-func GenerateID() string {
-	const charset = "0123456789abcdefghijklmnopqrstuvwxyz"
-	const length = 15
-	result := make([]byte, length)
-	for i := range result {
-		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		result[i] = charset[n.Int64()]
-	}
-	return string(result)
+	return norm.NFC.String(b.String())
 }
@@ -0,0 +1,584 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// testRoot is the root the parseNote tests' paths are relative to; nothing
+// is read from it.
+var testRoot = filepath.FromSlash("/vault")
+
+// testConfig is the command's default configuration, rooted at testRoot.
+func testConfig() models.Config {
+	return models.Config{
+		Root:               testRoot,
+		UIDFormat:          UIDShort,
+		ProjectID:          -1,
+		TagFromFolders:     true,
+		TagFromHashtags:    true,
+		TagFromFrontmatter: true,
+		TagSeparators:      ",;",
+		FlattenSeparator:   "-",
+		TagPrefixSeparator: "/",
+		MinTagLength:       2,
+		LongTagAction:      LongTagTruncate,
+		Format:             FormatMarkdown,
+		Frontmatter:        FrontmatterOff,
+		TitleSources:       DefaultTitleSources,
+		TemplatePosition:   TemplatePrepend,
+		DatePattern:        "2006-01-02",
+		Extensions:         DefaultExtensions,
+		OversizeAction:     OversizeSkip,
+	}
+}
+
+// parseTestNote runs parseNote on content as if it were the file rel (a
+// slash path) under testRoot.
+func parseTestNote(t *testing.T, cfg models.Config, rel, content string) models.Note {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	n, err := parseNote(cfg, filepath.Join(testRoot, filepath.FromSlash(rel)), []byte(content), now, now)
+	if err != nil {
+		t.Fatalf("parseNote(%s): %v", rel, err)
+	}
+	return n
+}
+
+func TestDateFromFilename(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		want   time.Time // zero means no date
+	}{
+		{"2024-03-15.md", "2006-01-02", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-15 Meeting.md", "2006-01-02", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"20240315-standup.md", "20060102", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"15.03.2024 Review.md", "02.01.2006", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"Meeting 2024-03-15.md", "2006-01-02", time.Time{}},
+		{"2024-13-45.md", "2006-01-02", time.Time{}},
+		{"short.md", "2006-01-02", time.Time{}},
+
+		// An empty layout tries the flexible formats
+		{"2024-03-15 Meeting.md", "", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-15_journal.md", "", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"Jan 2, 2024 Notes.md", "", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2 January 2024_journal.md", "", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"1234 Ideas.md", "", time.Time{}},
+		{"Ideas.md", "", time.Time{}},
+	}
+	for _, tt := range tests {
+		got, ok := dateFromFilename(tt.name, tt.layout)
+		if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("dateFromFilename(%q, %q) = %v, %v; want %v", tt.name, tt.layout, got, ok, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Server Notes", "server-notes"},
+		{"  snake_case name ", "snake-case-name"},
+		{"Café Notes", "cafe-notes"},
+		{"Ñandú Über", "nandu-uber"},
+		{"Straße", "strasse"},
+		{"Øresund Łódź", "oresund-lodz"},
+		{"日記", "日記"},
+		{"プロジェクト", "プロジェクト"},
+		{"中文 笔记", "中文-笔记"},
+		{"Привет Мир", "привет-мир"},
+		{"🎉🚀", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.in); got != tt.want {
+			t.Errorf("slugify(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFolderTagsKeepNonASCIINames(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want []string
+	}{
+		{"Café Notes/a.md", []string{"cafe-notes"}},
+		{"日記/2024/a.md", []string{"日記", "2024"}},
+		{"🎉/a.md", nil},
+		{"🎉/Über/a.md", []string{"uber"}},
+	}
+	for _, tt := range tests {
+		n := parseTestNote(t, testConfig(), tt.rel, "body\n")
+		if !reflect.DeepEqual(n.Tags, tt.want) {
+			t.Errorf("%s: tags %q; want %q", tt.rel, n.Tags, tt.want)
+		}
+	}
+}
+
+func TestStripTitleHeading(t *testing.T) {
+	tests := []struct {
+		name, content string
+		title, body   string
+	}{
+		{
+			name:    "heading at the top",
+			content: "# Top\n\nFirst paragraph.\n",
+			title:   "Top",
+			body:    "First paragraph.\n",
+		},
+		{
+			name:    "heading deeper in the file",
+			content: "Intro line.\n\n# Deeper\n\nMore text.\n",
+			title:   "Deeper",
+			body:    "Intro line.\n\nMore text.\n",
+		},
+		{
+			name:    "heading without a blank line after it",
+			content: "# Tight\nText.\n",
+			title:   "Tight",
+			body:    "Text.\n",
+		},
+		{
+			name:    "filename title leaves the body alone",
+			content: "No heading here.\n\n## Only an h2\n",
+			title:   "note",
+			body:    "No heading here.\n\n## Only an h2\n",
+		},
+	}
+	cfg := testConfig()
+	cfg.StripTitleHeading = true
+	for _, tt := range tests {
+		n := parseTestNote(t, cfg, "note.md", tt.content)
+		if n.Title != tt.title || n.Body != tt.body {
+			t.Errorf("%s: title %q, body %q; want %q, %q", tt.name, n.Title, n.Body, tt.title, tt.body)
+		}
+	}
+}
+
+func TestParseNoteNormalizesBOMAndLineEndings(t *testing.T) {
+	tests := []struct {
+		name, content string
+	}{
+		{"BOM", "\ufeff# Heading\n\nBody #tag\n"},
+		{"CRLF", "# Heading\r\n\r\nBody #tag\r\n"},
+		{"BOM and CRLF", "\ufeff# Heading\r\n\r\nBody #tag\r\n"},
+		{"CR", "# Heading\r\rBody #tag\r"},
+		{"BOM before frontmatter", "\ufeff---\r\ntitle: Heading\r\ntags: [tag]\r\n---\r\nBody\r\n"},
+	}
+	cfg := testConfig()
+	cfg.Frontmatter = FrontmatterParse
+	for _, tt := range tests {
+		n := parseTestNote(t, cfg, "note.md", tt.content)
+		if n.Title != "Heading" {
+			t.Errorf("%s: title %q; want %q", tt.name, n.Title, "Heading")
+		}
+		if strings.ContainsAny(n.Body, "\r\ufeff") {
+			t.Errorf("%s: body %q still has a BOM or carriage return", tt.name, n.Body)
+		}
+		if !reflect.DeepEqual(n.Tags, []string{"tag"}) {
+			t.Errorf("%s: tags %q; want [tag]", tt.name, n.Tags)
+		}
+	}
+}
+
+func TestFolderTagDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{"a", "b", "c", "d", "e"}},
+		{1, []string{"a"}},
+		{2, []string{"a", "b"}},
+		{9, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.FolderTagDepth = tt.depth
+		n := parseTestNote(t, cfg, "a/b/c/d/e/file.md", "body\n")
+		if !reflect.DeepEqual(n.Tags, tt.want) {
+			t.Errorf("depth %d: tags %q; want %q", tt.depth, n.Tags, tt.want)
+		}
+	}
+
+	// Notes directly under the root have no folder tags at any depth
+	cfg := testConfig()
+	cfg.FolderTagDepth = 1
+	if n := parseTestNote(t, cfg, "file.md", "body\n"); len(n.Tags) != 0 {
+		t.Errorf("root note: tags %q; want none", n.Tags)
+	}
+}
+
+func TestFlattenFolderTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		rel    string
+		sep    string
+		depth  int
+		prefix string
+		want   []string
+	}{
+		{"three levels", "cottage/foo/bar/file.md", "-", 0, "", []string{"cottage-foo-bar"}},
+		{"deeply nested", "a/b/c/d/e/f/g/file.md", "-", 0, "", []string{"a-b-c-d-e-f-g"}},
+		{"slugified segments", "My Vault/Client Work/Q1 Plans/file.md", "-", 0, "", []string{"my-vault-client-work-q1-plans"}},
+		{"custom separator", "cottage/foo/bar/file.md", ".", 0, "", []string{"cottage.foo.bar"}},
+		{"with depth", "a/b/c/d/e/file.md", "-", 2, "", []string{"a-b"}},
+		{"with prefix", "cottage/foo/bar/file.md", "-", 0, "vault1", []string{"vault1/cottage-foo-bar"}},
+		{"single folder", "cottage/file.md", "-", 0, "", []string{"cottage"}},
+		{"root note", "file.md", "-", 0, "", nil},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.FlattenFolderTags = true
+		cfg.FlattenSeparator = tt.sep
+		cfg.FolderTagDepth = tt.depth
+		cfg.FolderTagPrefix = tt.prefix
+		n := parseTestNote(t, cfg, tt.rel, "body\n")
+		if !reflect.DeepEqual(n.Tags, tt.want) {
+			t.Errorf("%s: tags %q; want %q", tt.name, n.Tags, tt.want)
+		}
+	}
+}
+
+func TestIsMarkdown(t *testing.T) {
+	exts := []string{"md", "markdown", "mdown"}
+	tests := []struct {
+		name string
+		exts []string
+		want bool
+	}{
+		{"note.md", nil, true},
+		{"NOTE.MD", nil, true},
+		{"note.markdown", nil, false},
+		{"note.md", exts, true},
+		{"Note.Md", exts, true},
+		{"note.markdown", exts, true},
+		{"note.MARKDOWN", exts, true},
+		{"note.mdown", exts, true},
+		{"note.txt", exts, false},
+		{"note.md.bak", exts, false},
+		{"markdown", exts, false},
+		{".md", exts, true},
+		{"note.mdown", []string{" .MDOWN "}, true},
+	}
+	for _, tt := range tests {
+		if got := isMarkdown(tt.exts, tt.name); got != tt.want {
+			t.Errorf("isMarkdown(%q, %q) = %v; want %v", tt.exts, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverNotesExtensions(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.md", "b.MD", "c.markdown", "d.mdown", "e.txt", "f.markdown.bak", "sub/g.Markdown"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := testConfig()
+	cfg.Root = root
+	cfg.Extensions = []string{"md", "markdown", "mdown"}
+	notes, _, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range notes {
+		got = append(got, filepath.ToSlash(n.RelPath))
+	}
+	if want := []string{"a.md", "b.MD", "c.markdown", "d.mdown", "sub/g.Markdown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("discovered %q; want %q", got, want)
+	}
+}
+
+func TestSkipDrafts(t *testing.T) {
+	tests := []struct {
+		name, content string
+		skipped       bool
+		metadata      string
+	}{
+		{"draft", "---\ndraft: true\n---\nText\n", true, ""},
+		{"unpublished", "---\npublish: false\n---\nText\n", true, ""},
+		{"draft false", "---\ndraft: false\nstatus: done\n---\nText\n", false, `{"status":"done"}`},
+		{"published", "---\npublish: true\n---\nText\n", false, ""},
+		{"no flags", "---\nstatus: done\n---\nText\n", false, `{"status":"done"}`},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.Frontmatter = FrontmatterParse
+		cfg.FrontmatterMeta = true
+		cfg.SkipDrafts = true
+		n, err := parseNote(cfg, filepath.Join(testRoot, "note.md"), []byte(tt.content), time.Time{}, time.Time{})
+		if tt.skipped {
+			if err != errDraft {
+				t.Errorf("%s: err %v; want errDraft", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if n.Metadata != tt.metadata {
+			t.Errorf("%s: metadata %q; want %q", tt.name, n.Metadata, tt.metadata)
+		}
+	}
+}
+
+func TestSkipDraftsOutsideParseMode(t *testing.T) {
+	for _, mode := range []string{FrontmatterOff, FrontmatterStrip} {
+		cfg := testConfig()
+		cfg.Frontmatter = mode
+		cfg.SkipDrafts = true
+		if _, err := parseNote(cfg, filepath.Join(testRoot, "note.md"), []byte("---\ndraft: true\n---\nText\n"), time.Time{}, time.Time{}); err != errDraft {
+			t.Errorf("%s: err %v; want errDraft", mode, err)
+		}
+		n := parseTestNote(t, cfg, "note.md", "---\npublish: true\n---\nText\n")
+		if want := map[string]string{FrontmatterOff: "---\npublish: true\n---\nText\n", FrontmatterStrip: "Text\n"}[mode]; n.Body != want {
+			t.Errorf("%s: body %q; want %q", mode, n.Body, want)
+		}
+	}
+}
+
+// The limit counts files picked up, so a draft dropped from the first root
+// still uses up its share.
+func TestDryRunLimitCountsFilesAcrossRoots(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(first, "a.md"):  "---\ndraft: true\n---\nDraft\n",
+		filepath.Join(first, "b.md"):  "Kept\n",
+		filepath.Join(second, "c.md"): "One\n",
+		filepath.Join(second, "d.md"): "Two\n",
+		filepath.Join(second, "e.md"): "Three\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := testConfig()
+	cfg.Root, cfg.Roots = first, []string{first, second}
+	cfg.Frontmatter = FrontmatterParse
+	cfg.SkipDrafts = true
+	cfg.DryRunLimit = 3
+	notes, stats, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range notes {
+		got = append(got, n.RelPath)
+	}
+	if want := []string{"b.md", "c.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("discovered %q; want %q", got, want)
+	}
+	if stats.Files != 3 || !stats.Limited || stats.Drafts != 1 {
+		t.Errorf("stats: %d files, limited %v, %d drafts; want 3, true, 1", stats.Files, stats.Limited, stats.Drafts)
+	}
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"three blanks", "a\n\n\n\nb", "a\n\nb"},
+		{"many blanks", "a\n\n\n\n\n\n\n\n\nb", "a\n\nb"},
+		{"two blanks kept", "a\n\n\nb", "a\n\n\nb"},
+		{"whitespace-only lines count as blank", "a\n \n\t\n  \nb", "a\n\nb"},
+		{"leading and trailing runs", "\n\n\n\na\n\n\n\n", "\na\n"},
+		{
+			"backtick fence left alone",
+			"a\n\n\n\n```\ncode\n\n\n\n\nmore\n```\n\n\n\nb",
+			"a\n\n```\ncode\n\n\n\n\nmore\n```\n\nb",
+		},
+		{
+			"tilde fence left alone",
+			"~~~go\nx\n\n\n\ny\n~~~\n\n\n\nz",
+			"~~~go\nx\n\n\n\ny\n~~~\n\nz",
+		},
+		{
+			"shorter marker doesn't close a longer fence",
+			"````\n```\n\n\n\n\n````\n\n\n\nend",
+			"````\n```\n\n\n\n\n````\n\nend",
+		},
+		{
+			"unclosed fence runs to the end",
+			"```\na\n\n\n\nb",
+			"```\na\n\n\n\nb",
+		},
+	}
+	for _, tt := range tests {
+		if got := collapseBlankLines(tt.in); got != tt.want {
+			t.Errorf("%s: collapseBlankLines(%q) = %q; want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHashtagsAcceptUnicode(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"Dinner at the #café tonight", []string{"cafe"}},
+		{"#Über and #niño", []string{"uber", "nino"}},
+		{"Travel #日本語 notes", []string{"日本語"}},
+		{"#中文标签。Next sentence", []string{"中文标签"}},
+		{"#привет, #мир!", []string{"привет", "мир"}},
+		{"#プロジェクト/進行中 nested", []string{"プロジェクト/進行中"}},
+		{"#work_2024 #a-b", []string{"work-2024", "a-b"}},
+		{"decomposed #cafe\u0301 accent", []string{"cafe"}},
+	}
+	for _, tt := range tests {
+		n := parseTestNote(t, testConfig(), "note.md", tt.body+"\n")
+		if !reflect.DeepEqual(n.Tags, tt.want) {
+			t.Errorf("%q: tags %q; want %q", tt.body, n.Tags, tt.want)
+		}
+	}
+}
+
+func TestTagsFromDifferentSourcesCollapse(t *testing.T) {
+	tests := []struct {
+		name, rel, content string
+		want               []string
+	}{
+		{"hashtag and folder", "work/note.md", "#Work\n", []string{"work"}},
+		{"hashtag and frontmatter", "note.md", "---\ntags: [Client_A]\n---\n#client-a\n", []string{"client-a"}},
+		{"nested hashtag and folders", "note.md", "#Projects/Big_Launch #projects/big-launch\n", []string{"projects/big-launch"}},
+		{"spacing and case", "My Notes/note.md", "#my-notes #MY-NOTES\n", []string{"my-notes"}},
+		{"distinct tags kept", "work/note.md", "#home\n", []string{"home", "work"}},
+	}
+	cfg := testConfig()
+	cfg.Frontmatter = FrontmatterParse
+	for _, tt := range tests {
+		n := parseTestNote(t, cfg, tt.rel, tt.content)
+		if !reflect.DeepEqual(n.Tags, tt.want) {
+			t.Errorf("%s: tags %q; want %q", tt.name, n.Tags, tt.want)
+		}
+	}
+}
+
+func TestContentHashIgnoresBodyTemplate(t *testing.T) {
+	cfg := testConfig()
+	plain := parseTestNote(t, cfg, "note.md", "Some text\n")
+	tmpl, err := ParseBodyTemplate("Imported {{.Date}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.BodyTmpl = tmpl
+	templated := parseTestNote(t, cfg, "note.md", "Some text\n")
+	if templated.Body == plain.Body {
+		t.Fatalf("template not applied: %q", templated.Body)
+	}
+	if templated.ContentHash != plain.ContentHash || plain.ContentHash != ContentHash("Some text\n") {
+		t.Errorf("hash %s with template, %s without; want both %s", templated.ContentHash, plain.ContentHash, ContentHash("Some text\n"))
+	}
+}
+
+func TestDatesFromGitOutsideRepository(t *testing.T) {
+	root := t.TempDir()
+	if InGitWorkTree(root) {
+		t.Skip("temp dir is inside a git work tree")
+	}
+	modTime := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	path := filepath.Join(root, "note.md")
+	if err := os.WriteFile(path, []byte("Text\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.Root = root
+	cfg.DatesFromGit = true
+	notes, stats, err := DiscoverNotes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || !notes[0].UpdatedAt.Equal(modTime) {
+		t.Fatalf("notes %+v; want one updated at %v", notes, modTime)
+	}
+	if len(stats.Warnings) != 1 || stats.Warnings[0].Path != root {
+		t.Errorf("warnings %v; want one for %s", stats.Warnings, root)
+	}
+}
+
+func TestFilesFromRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":      "drafts/\n*.tmp.md\n",
+		"sub/.gitignore":  "secret.md\n!keep.tmp.md\n",
+		"kept.md":         "Kept\n",
+		"drafts/a.md":     "Draft\n",
+		"x.tmp.md":        "Scratch\n",
+		"sub/secret.md":   "Secret\n",
+		"sub/keep.tmp.md": "Re-included\n",
+		"sub/ok.md":       "Fine\n",
+		".git/x.md":       "Internal\n",
+	}
+	var list []string
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, path)
+	}
+	listPath := filepath.Join(t.TempDir(), "files.txt")
+	if err := os.WriteFile(listPath, []byte(strings.Join(list, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.Root = root
+	cfg.RespectGitignore = true
+	want := []string{"kept.md", "sub/keep.tmp.md", "sub/ok.md"}
+	for _, filesFrom := range []string{"", listPath} {
+		cfg.FilesFrom = filesFrom
+		notes, _, err := DiscoverNotes(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, n := range notes {
+			got = append(got, filepath.ToSlash(n.RelPath))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("files-from %q: discovered %q; want %q", filesFrom, got, want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"database/sql"
+
+	"github.com/sottey/tududimport/internal/models"
+)
+
+// LoadNoteRefs reads the id, title and (when withSource) source_path of each
+// of the user's notes, ordered by id. It only reads from the database.
+func LoadNoteRefs(db *sql.DB, cfg models.Config, withSource bool) ([]models.NoteRef, error) {
+	sourceCol := "NULL"
+	if withSource {
+		sourceCol = "source_path"
+	}
+	selectSQL := `
+		SELECT id, title, ` + sourceCol + ` FROM notes
+		WHERE user_id = ?
+		ORDER BY id
+	`
+	rows, err := db.Query(DialectFor(cfg).Rebind(selectSQL), cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []models.NoteRef
+	for rows.Next() {
+		var (
+			ref           models.NoteRef
+			title, source sql.NullString
+		)
+		if err := rows.Scan(&ref.ID, &title, &source); err != nil {
+			return nil, err
+		}
+		ref.Title, ref.SourcePath = title.String, source.String
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// Reconcile matches discovered notes against the notes in the database, by
+// source_path or by title. Files with no matching note are Missing; database
+// notes matching no file are Orphaned.
+func Reconcile(notes []models.Note, refs []models.NoteRef) models.Reconciliation {
+	dbTitles := make(map[string]bool)
+	dbSources := make(map[string]bool)
+	for _, r := range refs {
+		dbTitles[r.Title] = true
+		if r.SourcePath != "" {
+			dbSources[r.SourcePath] = true
+		}
+	}
+	fileTitles := make(map[string]bool)
+	fileSources := make(map[string]bool)
+
+	var rec models.Reconciliation
+	for _, n := range notes {
+		fileTitles[n.Title] = true
+		fileSources[n.RelPath] = true
+		if dbSources[n.RelPath] || dbTitles[n.Title] {
+			rec.Matched++
+			continue
+		}
+		rec.Missing = append(rec.Missing, n)
+	}
+	for _, r := range refs {
+		if (r.SourcePath != "" && fileSources[r.SourcePath]) || fileTitles[r.Title] {
+			continue
+		}
+		rec.Orphaned = append(rec.Orphaned, r)
+	}
+	return rec
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 sottey
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// walkTree is filepath.Walk, optionally descending into symlinked directories.
+// When following links, each directory is visited once by its resolved path,
+// so symlink cycles are skipped and reported through warn.
+func walkTree(root string, follow bool, warn func(path, msg string), fn filepath.WalkFunc) error {
+	if !follow {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	w := &symlinkWalker{fn: fn, warn: warn, visited: make(map[string]bool)}
+	err = w.walk(root, info)
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+type symlinkWalker struct {
+	fn      filepath.WalkFunc
+	warn    func(path, msg string)
+	visited map[string]bool // resolved directory paths
+}
+
+func (w *symlinkWalker) walk(path string, info os.FileInfo) error {
+	if !info.IsDir() {
+		return w.fn(path, info, nil)
+	}
+
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		if abs, err := filepath.Abs(real); err == nil {
+			real = abs
+		}
+		if w.visited[real] {
+			w.warn(path, fmt.Sprintf("skipping %s: symlink cycle (already visited %s)", path, real))
+			return nil
+		}
+		w.visited[real] = true
+	}
+
+	if err := w.fn(path, info, nil); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return w.fn(path, info, err)
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := os.Stat(child) // follows symlinks
+		if err != nil {
+			// Broken link or unreadable entry: report it like filepath.Walk would
+			if lerr := w.fn(child, nil, err); lerr != nil && lerr != filepath.SkipDir {
+				return lerr
+			}
+			continue
+		}
+		if err := w.walk(child, childInfo); err != nil {
+			if err == filepath.SkipDir {
+				if childInfo.IsDir() {
+					continue
+				}
+				return nil // SkipDir on a file skips the rest of this directory
+			}
+			return err
+		}
+	}
+	return nil
+}